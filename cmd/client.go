@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/snapshot"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// newClient builds the Kubernetes client that a command runs against for the given kubeconfig context (an empty context means the
+// one selected by the normal flags). It is a variable so that embedders and tests can substitute a client backed by
+// k8s.io/client-go/kubernetes/fake via kubernetes.NewClientForInterface.
+var newClient = func(cmd *cobra.Command, kubeContext string) (*kubernetes.Client, error) {
+	opts := getConfigOptions(cmd)
+	if kubeContext != "" {
+		opts.Context = kubeContext
+	}
+	return kubernetes.NewClient(opts)
+}
+
+const (
+	// clusterHeader is the header of the cluster column that is added to tables when more than one cluster is shown
+	clusterHeader = "Cluster"
+	// allClustersName is used in place of a cluster name for rows that total every cluster
+	allClustersName = "All Clusters"
+)
+
+// clusterClient is a client along with the name of the cluster (kubeconfig context) that it talks to. The name is empty when the user
+// didn't ask for more than one cluster.
+type clusterClient struct {
+	*kubernetes.Client
+	name string
+}
+
+// newClusterClients builds a client for every cluster that the user asked for via --contexts or --all-contexts, or a single unnamed
+// client when neither was given. When --filename was given, a single offline client serving the objects from those files is returned.
+func newClusterClients(cmd *cobra.Command) ([]*clusterClient, error) {
+	contexts := getFlagStringSlice(cmd, "contexts")
+	filenames := getFlagStringSlice(cmd, "filename")
+	if len(filenames) > 0 {
+		if len(contexts) > 0 || getFlagBool(cmd, "all-contexts") {
+			return nil, fmt.Errorf("--filename cannot be combined with --contexts or --all-contexts")
+		}
+		client, err := newOfflineClient(cmd, filenames)
+		if err != nil {
+			return nil, err
+		}
+		return []*clusterClient{{Client: client}}, nil
+	}
+
+	if getFlagBool(cmd, "all-contexts") {
+		if len(contexts) > 0 {
+			return nil, fmt.Errorf("--contexts and --all-contexts cannot be used together")
+		}
+		var err error
+		contexts, err = kubernetes.ContextNames(getConfigOptions(cmd))
+		if err != nil {
+			return nil, err
+		}
+		if len(contexts) < 1 {
+			return nil, fmt.Errorf("no contexts were found in the kubeconfig")
+		}
+	}
+
+	if len(contexts) < 1 {
+		client, err := newClient(cmd, "")
+		if err != nil {
+			return nil, err
+		}
+		return []*clusterClient{{Client: client}}, nil
+	}
+
+	clients := make([]*clusterClient, 0, len(contexts))
+	for _, kubeContext := range contexts {
+		client, err := newClient(cmd, kubeContext)
+		if err != nil {
+			return nil, fmt.Errorf("context %s: %w", kubeContext, err)
+		}
+		clients = append(clients, &clusterClient{Client: client, name: kubeContext})
+	}
+
+	return clients, nil
+}
+
+// newOfflineClient builds a client that serves the objects read from the given files and snapshots
+func newOfflineClient(cmd *cobra.Command, filenames []string) (*kubernetes.Client, error) {
+	objs := make([]runtime.Object, 0)
+	for _, filename := range filenames {
+		var fileObjs []runtime.Object
+		var err error
+		if filename != kubernetes.StdinFilename && snapshot.IsSnapshot(filename) {
+			_, fileObjs, err = snapshot.Read(filename)
+		} else {
+			fileObjs, err = kubernetes.ReadObjectsFromFile(filename, cmd.InOrStdin())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read objects from %s: %w", filename, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+
+	return kubernetes.NewClientForObjects(objs...)
+}
+
+// isMultiCluster returns true when the clients span more than a single, unnamed cluster, which means that output needs a cluster column
+func isMultiCluster(clients []*clusterClient) bool {
+	return len(clients) > 1 || (len(clients) == 1 && clients[0].name != "")
+}
+
+// forEachCluster runs fn concurrently against every client and returns the results in the same order as the clients were given. If
+// any of the calls fail, the first failure (in client order) is returned.
+func forEachCluster[T any](clients []*clusterClient, fn func(*clusterClient) (T, error)) ([]T, error) {
+	results := make([]T, len(clients))
+	errs := make([]error, len(clients))
+
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func(i int, c *clusterClient) {
+			defer wg.Done()
+			results[i], errs[i] = fn(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			if clients[i].name != "" {
+				return nil, fmt.Errorf("cluster %s: %w", clients[i].name, err)
+			}
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// preflight verifies that the user is allowed to do everything the command needs before any lookups happen, so that missing RBAC
+// permissions result in a message naming the missing permission rather than a raw Forbidden error
+func preflight(ctx context.Context, cmd *cobra.Command, client *kubernetes.Client, checks ...kubernetes.AccessCheck) error {
+	if !getFlagBool(cmd, "preflight") {
+		return nil
+	}
+
+	err := client.CheckAccess(ctx, cmd.Name(), checks...)
+	if err != nil {
+		return fmt.Errorf("insufficient permissions: %w", err)
+	}
+
+	return nil
+}
+
+// withCluster returns the given table prefixes with a leading cluster column when more than one cluster is being shown. For header
+// rows the cluster should be passed as clusterHeader.
+func withCluster(multi bool, cluster string, prefixes ...string) []string {
+	if !multi {
+		return prefixes
+	}
+	return append([]string{cluster}, prefixes...)
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aauren/kube-quota/pkg/cli"
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/quota"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/klog/v2"
+)
+
+// clusterQuotaCmd represents the clusterquota command
+var clusterQuotaCmd = &cobra.Command{
+	Use:     "clusterquota [name]",
+	Aliases: []string{"crq"},
+	Short:   "See the usage of OpenShift ClusterResourceQuotas across every namespace that they select",
+	Args:    cobra.MaximumNArgs(1),
+	Run:     clusterQuotaRun,
+}
+
+func init() {
+	rootCmd.AddCommand(clusterQuotaCmd)
+
+	clusterQuotaCmd.Flags().StringP("namespace", "n", "", "only show the cluster quotas that apply to this namespace, their usage "+
+		"is read from their status through AppliedClusterResourceQuotas rather than added up from every namespace")
+	clusterQuotaCmd.Flags().Bool("show-namespaces", false, "break the usage of each cluster quota down into one row per namespace "+
+		"that it selects")
+	clusterQuotaCmd.Flags().Int("concurrency", defaultNamespaceConcurrency, "number of namespaces to query at once")
+}
+
+// clusterQuotaUsage is a single ClusterResourceQuota along with the usage of every namespace that it selects
+type clusterQuotaUsage struct {
+	q          *quota.KubeQuota
+	namespaces []string
+	// workloads holds the usage of each selected namespace that counts against the quota, which is only some of its pods when the quota
+	// has scopes
+	workloads map[string]*quota.WorkloadQuota
+	total     *quota.WorkloadQuota
+	// fromStatus is true when the usage was taken from the quota's status rather than summed up from the namespaces
+	fromStatus bool
+}
+
+// clusterQuotaResult holds every ClusterResourceQuota that was found in a single cluster
+type clusterQuotaResult struct {
+	cluster string
+	quotas  []*clusterQuotaUsage
+}
+
+func clusterQuotaRun(cmd *cobra.Command, args []string) {
+	if getFlagInt(cmd, "concurrency") < 1 {
+		klog.Fatalf("Encountered error while parsing input: --concurrency must be at least 1")
+	}
+
+	// Create our context and get any arguments the user may have set
+	ctx := cmd.Context()
+	ns := getFlagString(cmd, "namespace")
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	clients, err := newClusterClients(cmd)
+	if err != nil {
+		exitWithError("could not create kubernetes client", err)
+	}
+
+	results, err := forEachCluster(clients, func(c *clusterClient) (*clusterQuotaResult, error) {
+		if ns != "" {
+			return getAppliedClusterQuotaResult(ctx, cmd, c, ns, name)
+		}
+		return getClusterQuotaResult(ctx, cmd, c, name, getFlagInt(cmd, "concurrency"))
+	})
+	if err != nil {
+		exitWithError("could not get cluster quota data", err)
+	}
+	renderClusterQuotaTable(cmd, results, isMultiCluster(clients), getFlagBool(cmd, "show-namespaces"))
+}
+
+// listClusterQuotas lists the ClusterResourceQuotas that apply to a namespace, or every one of them when no namespace is given, and keeps
+// only the one with the given name when there is one
+func listClusterQuotas(ctx context.Context, cmd *cobra.Command, c *clusterClient, ns, name string) ([]*kubernetes.ClusterResourceQuota,
+	error) {
+	err := preflight(ctx, cmd, c.Client, kubernetes.ClusterResourceQuotaAccessChecks(ns)...)
+	if err != nil {
+		return nil, err
+	}
+	crqs, err := c.ListClusterResourceQuotas(ctx, ns)
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("the cluster doesn't serve ClusterResourceQuotas, they are only available on OpenShift: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get cluster resource quotas: %w", err)
+	}
+
+	selected := make([]*kubernetes.ClusterResourceQuota, 0, len(crqs))
+	for _, crq := range crqs {
+		if name != "" && crq.Name != name {
+			continue
+		}
+		selected = append(selected, crq)
+	}
+	if len(selected) < 1 {
+		switch {
+		case name != "":
+			return nil, fmt.Errorf("no cluster resource quota named %s existed", name)
+		case ns != "":
+			return nil, fmt.Errorf("no cluster resource quotas applied to namespace %s", ns)
+		default:
+			return nil, fmt.Errorf("no cluster resource quotas existed")
+		}
+	}
+	return selected, nil
+}
+
+// getAppliedClusterQuotaResult finds the ClusterResourceQuotas that apply to a namespace. Users that can only see that namespace can't
+// add up the usage of the others, so the usage is taken from each quota's status instead.
+func getAppliedClusterQuotaResult(ctx context.Context, cmd *cobra.Command, c *clusterClient, ns, name string) (*clusterQuotaResult,
+	error) {
+	crqs, err := listClusterQuotas(ctx, cmd, c, ns, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := clusterQuotaResult{cluster: c.name}
+	for _, crq := range crqs {
+		cq := clusterQuotaUsage{
+			q:          quota.ForKubeQuota(crq.ResourceQuota()),
+			namespaces: make([]string, 0, len(crq.Status.Namespaces)),
+			workloads:  make(map[string]*quota.WorkloadQuota, len(crq.Status.Namespaces)),
+			fromStatus: true,
+		}
+		cq.total = cq.q.UsedWorkload()
+		for _, nsStatus := range crq.Status.Namespaces {
+			rq, _ := crq.NamespaceResourceQuota(nsStatus.Namespace)
+			cq.namespaces = append(cq.namespaces, nsStatus.Namespace)
+			cq.workloads[nsStatus.Namespace] = quota.ForKubeQuota(rq).UsedWorkload()
+		}
+		slices.Sort(cq.namespaces)
+		r.quotas = append(r.quotas, &cq)
+	}
+	return &r, nil
+}
+
+// getClusterQuotaResult finds the ClusterResourceQuotas of a cluster along with the namespaces that each of them selects, and adds up
+// the usage of those namespaces querying at most concurrency namespaces at a time
+func getClusterQuotaResult(ctx context.Context, cmd *cobra.Command, c *clusterClient, name string,
+	concurrency int) (*clusterQuotaResult, error) {
+	crqs, err := listClusterQuotas(ctx, cmd, c, "", name)
+	if err != nil {
+		return nil, err
+	}
+	nsl, err := c.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get namespaces: %w", err)
+	}
+
+	// Every namespace is only queried once, no matter how many of the quotas select it
+	r := clusterQuotaResult{cluster: c.name}
+	byNS := make(map[string][]*quota.KubeQuota)
+	namespaces := make([]string, 0)
+	for _, crq := range crqs {
+		cq := clusterQuotaUsage{q: quota.ForKubeQuota(crq.ResourceQuota()), namespaces: make([]string, 0)}
+		for idx := range nsl.Items {
+			selected, err := crq.Selects(&nsl.Items[idx])
+			if err != nil {
+				return nil, err
+			}
+			if !selected {
+				continue
+			}
+			cq.namespaces = append(cq.namespaces, nsl.Items[idx].Name)
+		}
+		r.quotas = append(r.quotas, &cq)
+		for _, nsName := range cq.namespaces {
+			if _, ok := byNS[nsName]; !ok {
+				namespaces = append(namespaces, nsName)
+			}
+			byNS[nsName] = append(byNS[nsName], cq.q)
+		}
+	}
+
+	err = preflightNamespaces(ctx, cmd, c, namespaces, byNS, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	o := workloadOptions{}
+	nsWorkloads, err := forEachNamespace(namespaces, concurrency, func(nsName string) (*namespaceWorkload, error) {
+		return getNamespaceWorkload(ctx, c, &o, nsName, byNS[nsName])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cq := range r.quotas {
+		cq.workloads = make(map[string]*quota.WorkloadQuota, len(cq.namespaces))
+		wqs := make([]*quota.WorkloadQuota, 0, len(cq.namespaces))
+		for _, nsName := range cq.namespaces {
+			wq := nsWorkloads[nsName].total
+			if scoped, ok := nsWorkloads[nsName].scoped[cq.q.Name]; ok {
+				wq = scoped
+			}
+			cq.workloads[nsName] = wq
+			wqs = append(wqs, wq)
+		}
+		cq.total = quota.SumWorkloadQuotas(wqs...)
+	}
+	return &r, nil
+}
+
+// renderClusterQuotaTable shows the usage of every ClusterResourceQuota across the namespaces that it selects, followed by the quota
+// itself and the usage from its status when the usage was summed up rather than taken from the status
+func renderClusterQuotaTable(cmd *cobra.Command, results []*clusterQuotaResult, multi, showNamespaces bool) {
+	tbl := cli.CreateTableWriter(cmd.OutOrStdout())
+	headerers := make([]cli.TableHeaderer, 0)
+	for _, r := range results {
+		for _, cq := range r.quotas {
+			headerers = append(headerers, cq.q, cq.total)
+		}
+	}
+	cli.AddTableHeader(tbl, withCluster(multi, clusterHeader, "Name", "Namespace"), headerers...)
+
+	addRow := func(cluster, name, label string, hv cli.HeaderValuer) {
+		err := cli.AddRow(tbl, hv, withCluster(multi, cluster, name, label))
+		if err != nil {
+			klog.Fatalf("Could not add row to table: %v", err)
+		}
+	}
+
+	for _, r := range results {
+		for _, cq := range r.quotas {
+			name := withScopes(cq.q.Name, cq.q)
+			if showNamespaces {
+				for _, ns := range cq.namespaces {
+					addRow(r.cluster, name, ns, &quota.WorkloadUsage{KQ: cq.q, WQ: cq.workloads[ns]})
+				}
+			}
+			addRow(r.cluster, name, namespaceCountLabel(len(cq.namespaces)), &quota.WorkloadUsage{KQ: cq.q, WQ: cq.total})
+			addRow(r.cluster, name, "Quota", cq.q)
+			if cq.q.HasStatus() && !cq.fromStatus {
+				addRow(r.cluster, name, withDrift("Status", cq.q, cq.total),
+					quota.NewDelta(&quota.QuotaStatus{KQ: cq.q}, cq.q.UsedWorkload(), cq.total))
+			}
+		}
+	}
+
+	tbl.Render()
+}
+
+// namespaceCountLabel labels the total row of a cluster quota with the number of namespaces that it was added up from
+func namespaceCountLabel(count int) string {
+	if count == 1 {
+		return "Total (1 namespace)"
+	}
+	return fmt.Sprintf("Total (%d namespaces)", count)
+}
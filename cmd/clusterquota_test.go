@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+)
+
+// clusterQuotaYAML holds a quota over two namespaces whose status doesn't match their pods, so that the rows show where the usage
+// was read from
+const clusterQuotaYAML = `apiVersion: v1
+kind: Namespace
+metadata: {name: dev, labels: {team: a}}
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: prod, labels: {team: a}}
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: other}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: app, namespace: dev}
+spec:
+  containers:
+  - {name: app, image: app, resources: {requests: {cpu: "1"}}}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: app, namespace: prod}
+spec:
+  containers:
+  - {name: app, image: app, resources: {requests: {cpu: "1"}}}
+---
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata: {name: team-a}
+spec:
+  selector:
+    labels: {matchLabels: {team: a}}
+  quota:
+    hard: {requests.cpu: "4"}
+status:
+  total:
+    hard: {requests.cpu: "4"}
+    used: {requests.cpu: "3"}
+  namespaces:
+  - namespace: prod
+    status:
+      hard: {requests.cpu: "4"}
+      used: {requests.cpu: "2"}
+  - namespace: dev
+    status:
+      hard: {requests.cpu: "4"}
+      used: {requests.cpu: "1"}
+`
+
+func TestClusterQuotaCommand(t *testing.T) {
+	out := runKubeQuota(t, nil, "clusterquota", "-f", writeObjectsFile(t, clusterQuotaYAML), "--show-namespaces")
+	assertRow(t, out, []string{"team-a", "dev"}, []string{"1.0 Cores (25.00%)", "0 B", "0 Millicores", "0 B"})
+	assertRow(t, out, []string{"team-a", "prod"}, []string{"1.0 Cores (25.00%)", "0 B", "0 Millicores", "0 B"})
+	assertRow(t, out, []string{"team-a", "Total (2 namespaces)"}, []string{"2.0 Cores (50.00%)", "0 B", "0 Millicores", "0 B"})
+	assertRow(t, out, []string{"team-a", "Quota"}, []string{"4.0 Cores", "", "", ""})
+	assertRow(t, out, []string{"team-a", "Status (drift: CPU Request)"}, []string{"3.0 Cores (75.00%) [-1.0 Cores]", "", "", ""})
+}
+
+func TestClusterQuotaCommandNamespace(t *testing.T) {
+	// Only the quota's status is read, so the usage matches it even though the pods don't
+	out := runKubeQuota(t, nil, "clusterquota", "-f", writeObjectsFile(t, clusterQuotaYAML), "-n", "dev", "--show-namespaces")
+	assertRow(t, out, []string{"team-a", "dev"}, []string{"1.0 Cores (25.00%)", "0 B", "0 Millicores", "0 B"})
+	assertRow(t, out, []string{"team-a", "prod"}, []string{"2.0 Cores (50.00%)", "0 B", "0 Millicores", "0 B"})
+	assertRow(t, out, []string{"team-a", "Total (2 namespaces)"}, []string{"3.0 Cores (75.00%)", "0 B", "0 Millicores", "0 B"})
+	assertRow(t, out, []string{"team-a", "Quota"}, []string{"4.0 Cores", "", "", ""})
+	if row := tableRow(out, "team-a", "Status"); row != nil {
+		t.Errorf("unexpected status row %q", row)
+	}
+}
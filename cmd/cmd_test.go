@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var colorEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// useFakeClientset makes every command of the test run against a fake clientset holding objs, which is returned so that the test can
+// change the objects. Every SelfSubjectAccessReview is allowed so that preflight runs as well.
+func useFakeClientset(t *testing.T, objs []runtime.Object) *fake.Clientset {
+	t.Helper()
+
+	cs := fake.NewSimpleClientset(objs...)
+	cs.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ssar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		ssar.Status.Allowed = true
+		return true, ssar, nil
+	})
+
+	orig := newClient
+	newClient = func(*cobra.Command, string) (*kubernetes.Client, error) {
+		return kubernetes.NewClientForInterface(cs), nil
+	}
+	t.Cleanup(func() {
+		newClient = orig
+		resetFlags(rootCmd)
+	})
+	return cs
+}
+
+// runKubeQuota runs kube-quota with the given arguments against a fake clientset holding objs, and returns its output without colors
+func runKubeQuota(t *testing.T, objs []runtime.Object, args ...string) string {
+	t.Helper()
+	useFakeClientset(t, objs)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs(args)
+	err := rootCmd.ExecuteContext(context.Background())
+	if err != nil {
+		t.Fatalf("kube-quota %s: %v", strings.Join(args, " "), err)
+	}
+	return colorEscape.ReplaceAllString(out.String(), "")
+}
+
+// writeObjectsFile writes the YAML manifests to a file for kube-quota to read with --filename and returns its path
+func writeObjectsFile(t *testing.T, manifests string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "objects.yaml")
+	err := os.WriteFile(p, []byte(manifests), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// resetFlags puts every flag of the command and its subcommands back to its default, cobra keeps flag values between executions
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// tableRow returns the cells of the first table row whose leading cells are prefix, or nil when there is no such row
+func tableRow(out string, prefix ...string) []string {
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		if len(cells) >= len(prefix) && slices.Equal(cells[:len(prefix)], prefix) {
+			return cells
+		}
+	}
+	return nil
+}
+
+// assertRow fails the test unless the table has a row with the given leading cells followed by want
+func assertRow(t *testing.T, out string, prefix, want []string) {
+	t.Helper()
+	row := tableRow(out, prefix...)
+	if row == nil {
+		t.Fatalf("no row starting with %q in:\n%s", prefix, out)
+	}
+	if got := row[len(prefix):]; !slices.Equal(got, want) {
+		t.Errorf("row %q = %q, want %q", prefix, got, want)
+	}
+}
+
+func testPod(ns, name, cpuReq, memReq, cpuLim, memLim string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name: "app",
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpuReq), v1.ResourceMemory: resource.MustParse(memReq)},
+				Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpuLim), v1.ResourceMemory: resource.MustParse(memLim)},
+			},
+		}}},
+	}
+}
+
+func testQuota(ns, name, cpuReq, memReq, cpuLim, memLim string) *v1.ResourceQuota {
+	return &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: v1.ResourceQuotaSpec{Hard: v1.ResourceList{
+			v1.ResourceRequestsCPU:    resource.MustParse(cpuReq),
+			v1.ResourceRequestsMemory: resource.MustParse(memReq),
+			v1.ResourceLimitsCPU:      resource.MustParse(cpuLim),
+			v1.ResourceLimitsMemory:   resource.MustParse(memLim),
+		}},
+	}
+}
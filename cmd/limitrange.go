@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aauren/kube-quota/pkg/cli"
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/quota"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// limitRangeCmd represents the limitrange command
+var limitRangeCmd = &cobra.Command{
+	Use:   "limitrange",
+	Short: "See the limit ranges that set the minimum, maximum and default requests and limits of a namespace",
+	Args:  cobra.NoArgs,
+	Run:   limitRangeRun,
+}
+
+func init() {
+	rootCmd.AddCommand(limitRangeCmd)
+
+	limitRangeCmd.Flags().StringP("namespace", "n", "", "namespace to search within")
+	limitRangeCmd.Flags().BoolP("all-namespaces", "A", false, "show every limit range in every namespace")
+}
+
+func limitRangeRun(cmd *cobra.Command, _ []string) {
+	err := validateNamespaceFlags(cmd)
+	if err != nil {
+		klog.Fatalf("Encountered error while parsing input: %v", err)
+	}
+
+	// Create our context and get any arguments the user may have set
+	ctx := cmd.Context()
+	ns := getFlagString(cmd, "namespace")
+	all := getFlagBool(cmd, "all-namespaces")
+	if all {
+		ns = metav1.NamespaceAll
+	}
+
+	clients, err := newClusterClients(cmd)
+	if err != nil {
+		exitWithError("could not create kubernetes client", err)
+	}
+
+	lrs, err := forEachCluster(clients, func(c *clusterClient) ([]v1.LimitRange, error) {
+		err := preflight(ctx, cmd, c.Client, kubernetes.LimitRangeAccessChecks(ns)...)
+		if err != nil {
+			return nil, err
+		}
+		lrl, err := c.ListLimitRangesByNS(ctx, ns)
+		if err != nil {
+			return nil, fmt.Errorf("could not get limit ranges: %w", err)
+		}
+		if len(lrl.Items) < 1 {
+			if all {
+				return nil, fmt.Errorf("no limit ranges existed in any namespace")
+			}
+			return nil, fmt.Errorf("no limit ranges existed in namespace %s, please try a different namespace", ns)
+		}
+		sort.Slice(lrl.Items, func(i, j int) bool {
+			if lrl.Items[i].Namespace != lrl.Items[j].Namespace {
+				return lrl.Items[i].Namespace < lrl.Items[j].Namespace
+			}
+			return lrl.Items[i].Name < lrl.Items[j].Name
+		})
+		return lrl.Items, nil
+	})
+	if err != nil {
+		exitWithError("could not get limit range data", err)
+	}
+
+	// Setup our table and add our header, every resource of every limit range item gets its own row
+	multi := isMultiCluster(clients)
+	prefix := []string{"Name", "Type", "Resource"}
+	if all {
+		prefix = append([]string{"Namespace"}, prefix...)
+	}
+	tbl := cli.CreateTableWriter(cmd.OutOrStdout())
+	cli.AddTableHeader(tbl, withCluster(multi, clusterHeader, prefix...), &quota.LimitRangeRow{})
+
+	for i, clusterLRs := range lrs {
+		for idx := range clusterLRs {
+			lr := &clusterLRs[idx]
+			for _, row := range quota.LimitRangeRows(lr) {
+				prefix := []string{row.Name, string(row.Type), string(row.Resource)}
+				if all {
+					prefix = append([]string{lr.Namespace}, prefix...)
+				}
+				err = cli.AddRow(tbl, row, withCluster(multi, clients[i].name, prefix...))
+				if err != nil {
+					klog.Fatalf("Could not add row to table: %v", err)
+				}
+			}
+		}
+	}
+
+	// Render our table
+	tbl.Render()
+}
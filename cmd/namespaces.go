@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aauren/kube-quota/pkg/cli"
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/quota"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultNamespaceConcurrency is the number of namespaces that --all-namespaces queries at once unless --concurrency says otherwise
+	defaultNamespaceConcurrency = 8
+)
+
+// validateNamespaceFlags makes sure that a command was given either a namespace or --all-namespaces, but not both
+func validateNamespaceFlags(cmd *cobra.Command) error {
+	ns, all := getFlagString(cmd, "namespace"), getFlagBool(cmd, "all-namespaces")
+	if ns == "" && !all {
+		return fmt.Errorf("either --namespace or --all-namespaces is required")
+	}
+	if ns != "" && all {
+		return fmt.Errorf("--namespace and --all-namespaces cannot be used together")
+	}
+	return nil
+}
+
+// namespacedQuota is a single quota that was found by --all-namespaces
+type namespacedQuota struct {
+	ns   string
+	name string
+	q    *quota.KubeQuota
+}
+
+// listAllQuotas lists the quotas in every namespace, sorted by namespace and then name. When a name is given only quotas with that name
+// are returned.
+func listAllQuotas(ctx context.Context, c *clusterClient, name string) ([]*namespacedQuota, error) {
+	rql, err := c.ListQuotasByNS(ctx, metav1.NamespaceAll)
+	if err != nil {
+		return nil, fmt.Errorf("could not list quotas in all namespaces: %w", err)
+	}
+
+	nqs := make([]*namespacedQuota, 0, len(rql.Items))
+	for idx := range rql.Items {
+		rq := &rql.Items[idx]
+		if name != "" && rq.Name != name {
+			continue
+		}
+		nqs = append(nqs, &namespacedQuota{ns: rq.Namespace, name: rq.Name, q: quota.ForKubeQuota(rq)})
+	}
+	sort.Slice(nqs, func(i, j int) bool {
+		if nqs[i].ns != nqs[j].ns {
+			return nqs[i].ns < nqs[j].ns
+		}
+		return nqs[i].name < nqs[j].name
+	})
+
+	return nqs, nil
+}
+
+// quotaNamespaces returns every namespace that holds one of the quotas, in the same order as the quotas
+func quotaNamespaces(nqs []*namespacedQuota) []string {
+	namespaces := make([]string, 0, len(nqs))
+	for _, nq := range nqs {
+		if len(namespaces) == 0 || namespaces[len(namespaces)-1] != nq.ns {
+			namespaces = append(namespaces, nq.ns)
+		}
+	}
+	return namespaces
+}
+
+// effectiveQuotas returns the effective quota of every namespace that holds one of the quotas, keyed by namespace
+func effectiveQuotas(nqs []*namespacedQuota) map[string]*quota.KubeQuota {
+	byNS := make(map[string][]*quota.KubeQuota)
+	for _, nq := range nqs {
+		byNS[nq.ns] = append(byNS[nq.ns], nq.q)
+	}
+
+	effective := make(map[string]*quota.KubeQuota, len(byNS))
+	for ns, kqs := range byNS {
+		effective[ns] = effectiveQuota(kqs)
+	}
+	return effective
+}
+
+// forEachNamespace runs fn for every namespace with at most limit calls running at once, and returns the results keyed by namespace. If
+// any of the calls fail, the first failure (in namespace order) is returned.
+func forEachNamespace[T any](namespaces []string, limit int, fn func(ns string) (T, error)) (map[string]T, error) {
+	results := make([]T, len(namespaces))
+	errs := make([]error, len(namespaces))
+
+	// sem holds a token for every call that is running, so that no more than limit of them run at once
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, ns := range namespaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, ns string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = fn(ns)
+		}(i, ns)
+	}
+	wg.Wait()
+
+	byNS := make(map[string]T, len(namespaces))
+	for i, ns := range namespaces {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("namespace %s: %w", ns, errs[i])
+		}
+		byNS[ns] = results[i]
+	}
+
+	return byNS, nil
+}
+
+// allNamespacesResult holds everything that --all-namespaces gathered from a single cluster
+type allNamespacesResult struct {
+	cluster string
+	quotas  []*namespacedQuota
+	// workloads holds the total of every namespace that has a quota, it is nil for commands that only look at quotas
+	workloads map[string]*quota.WorkloadQuota
+	// scoped holds the usage of every quota with scopes keyed by namespace and then quota name, it is nil when workloads is
+	scoped map[string]map[string]*quota.WorkloadQuota
+}
+
+// namespaceWorkload is the usage of a single namespace that --all-namespaces gathered
+type namespaceWorkload struct {
+	total  *quota.WorkloadQuota
+	scoped map[string]*quota.WorkloadQuota
+}
+
+// workloadFor returns the usage that a quota is compared against, which is the namespace total unless the quota has scopes
+func (r *allNamespacesResult) workloadFor(nq *namespacedQuota) *quota.WorkloadQuota {
+	if r.workloads == nil {
+		return nil
+	}
+	if wq, ok := r.scoped[nq.ns][nq.name]; ok {
+		return wq
+	}
+	return r.workloads[nq.ns]
+}
+
+// total returns the sum of every namespace's effective quota along with the sum of every namespace's workload (when workloads were
+// gathered)
+func (r *allNamespacesResult) total() (*quota.KubeQuota, *quota.WorkloadQuota) {
+	kqs := make([]*quota.KubeQuota, 0, len(r.quotas))
+	for _, eq := range effectiveQuotas(r.quotas) {
+		kqs = append(kqs, eq)
+	}
+
+	var wq *quota.WorkloadQuota
+	if r.workloads != nil {
+		wqs := make([]*quota.WorkloadQuota, 0, len(r.workloads))
+		for _, nsTotal := range r.workloads {
+			wqs = append(wqs, nsTotal)
+		}
+		wq = quota.SumWorkloadQuotas(wqs...)
+	}
+
+	return quota.SumKubeQuotas(kqs...), wq
+}
+
+// getAllNamespacesWorkload finds every namespace that has a quota and adds up the pods of each one, querying at most
+// concurrency namespaces at a time
+func getAllNamespacesWorkload(ctx context.Context, cmd *cobra.Command, c *clusterClient, o *workloadOptions,
+	concurrency int) (*allNamespacesResult, error) {
+	err := preflight(ctx, cmd, c.Client, kubernetes.QuotaAccessChecks(metav1.NamespaceAll, "")...)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := listAllQuotas(ctx, c, o.quotaName)
+	if err != nil {
+		return nil, err
+	}
+
+	byNS := make(map[string][]*quota.KubeQuota)
+	for _, nq := range quotas {
+		byNS[nq.ns] = append(byNS[nq.ns], nq.q)
+	}
+
+	namespaces := quotaNamespaces(quotas)
+	err = preflightNamespaces(ctx, cmd, c, namespaces, byNS, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	nsWorkloads, err := forEachNamespace(namespaces, concurrency, func(ns string) (*namespaceWorkload, error) {
+		return getNamespaceWorkload(ctx, c, o, ns, byNS[ns])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := allNamespacesResult{
+		cluster:   c.name,
+		quotas:    quotas,
+		workloads: make(map[string]*quota.WorkloadQuota, len(nsWorkloads)),
+		scoped:    make(map[string]map[string]*quota.WorkloadQuota, len(nsWorkloads)),
+	}
+	for ns, nw := range nsWorkloads {
+		r.workloads[ns], r.scoped[ns] = nw.total, nw.scoped
+	}
+	return &r, nil
+}
+
+// getNamespaceWorkload adds up the pods, claims and counted objects of a namespace, along with the usage of each of the quotas with
+// scopes that apply to it
+func getNamespaceWorkload(ctx context.Context, c *clusterClient, o *workloadOptions, ns string,
+	kqs []*quota.KubeQuota) (*namespaceWorkload, error) {
+	nq := quota.NewNamespaceWorkloadQuota(ns, false)
+	scoped := quota.NewScopedUsage(ns, kqs)
+	lrd, err := getLimitRangeDefaults(ctx, c, ns)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = c.ForEachPod(ctx, ns, kubernetes.PodSelector{}, func(pod *v1.Pod) error {
+		if pod = lrd.Apply(pod); !o.skipPod(pod, now) {
+			pq := quota.QuotaForPod(pod)
+			nq.AddPodQuota(pq)
+			scoped.AddPod(pod, pq)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get pods by namespace: %w", err)
+	}
+	err = addClaims(ctx, c, nq)
+	if err != nil {
+		return nil, err
+	}
+	err = addObjectCounts(ctx, c, nq, kqs)
+	if err != nil {
+		return nil, err
+	}
+
+	nw := namespaceWorkload{total: nq.Sum(), scoped: make(map[string]*quota.WorkloadQuota)}
+	for _, kq := range kqs {
+		if kq.Scoped() {
+			nw.scoped[kq.Name] = scoped.For(kq, nq).Sum()
+		}
+	}
+	return &nw, nil
+}
+
+// namespaceWorkloadAccessChecks returns the permissions that getNamespaceWorkload needs
+func namespaceWorkloadAccessChecks(ns string, kqs []*quota.KubeQuota) []kubernetes.AccessCheck {
+	checks := kubernetes.PodAccessChecks(ns)
+	checks = append(checks, kubernetes.ClaimAccessChecks(ns)...)
+	return append(checks, objectAccessChecks(ns, kqs)...)
+}
+
+// preflightNamespaces verifies that the user can read the workloads of every one of the namespaces. Being allowed to read them across
+// the whole cluster covers every namespace with a single round of checks, so each namespace is only checked on its own (concurrency at a
+// time) when that is denied.
+func preflightNamespaces(ctx context.Context, cmd *cobra.Command, c *clusterClient, namespaces []string,
+	byNS map[string][]*quota.KubeQuota, concurrency int) error {
+	if !getFlagBool(cmd, "preflight") || len(namespaces) < 1 {
+		return nil
+	}
+
+	kqs := make([]*quota.KubeQuota, 0)
+	for _, ns := range namespaces {
+		kqs = append(kqs, byNS[ns]...)
+	}
+	err := c.CheckAccess(ctx, cmd.Name(), namespaceWorkloadAccessChecks(metav1.NamespaceAll, kqs)...)
+	var mae *kubernetes.MissingAccessError
+	if !errors.As(err, &mae) {
+		if err != nil {
+			return fmt.Errorf("insufficient permissions: %w", err)
+		}
+		return nil
+	}
+	klog.V(2).Infof("Checking the permissions of each namespace, as they can't be read across the cluster: %v", err)
+
+	nsMissing, err := forEachNamespace(namespaces, concurrency, func(ns string) ([]kubernetes.AccessCheck, error) {
+		err := c.CheckAccess(ctx, cmd.Name(), namespaceWorkloadAccessChecks(ns, byNS[ns])...)
+		var nsMAE *kubernetes.MissingAccessError
+		if errors.As(err, &nsMAE) {
+			return nsMAE.Missing, nil
+		}
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("insufficient permissions: %w", err)
+	}
+	missing := make([]kubernetes.AccessCheck, 0)
+	for _, ns := range namespaces {
+		missing = append(missing, nsMissing[ns]...)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("insufficient permissions: %w", &kubernetes.MissingAccessError{Command: cmd.Name(), Missing: missing})
+	}
+	return nil
+}
+
+// renderAllNamespacesTable shows a row for every namespace and quota in every result, followed by a total row for each cluster.
+// Namespaces with more than one quota get an extra row for their effective quota. With workloads each row shows the namespace's usage of
+// that quota, otherwise it shows the quota itself.
+func renderAllNamespacesTable(cmd *cobra.Command, results []*allNamespacesResult, multi bool) {
+	tbl := cli.CreateTableWriter(cmd.OutOrStdout())
+	headerers := make([]cli.TableHeaderer, 0)
+	for _, r := range results {
+		for _, nq := range r.quotas {
+			headerers = append(headerers, nq.q)
+		}
+		for _, wq := range r.workloads {
+			headerers = append(headerers, wq)
+		}
+	}
+	cli.AddTableHeader(tbl, withCluster(multi, clusterHeader, "Namespace", "Quota"), headerers...)
+
+	addRow := func(cluster, ns, name string, q *quota.KubeQuota, wq *quota.WorkloadQuota) {
+		var hv cli.HeaderValuer = q
+		if wq != nil {
+			hv = &quota.WorkloadUsage{KQ: q, WQ: wq}
+		}
+		err := cli.AddRow(tbl, hv, withCluster(multi, cluster, ns, name))
+		if err != nil {
+			klog.Fatalf("Could not add row to table: %v", err)
+		}
+	}
+
+	allQuotas := make([]*quota.KubeQuota, 0, len(results))
+	allWorkloads := make([]*quota.WorkloadQuota, 0, len(results))
+	for _, r := range results {
+		effective := effectiveQuotas(r.quotas)
+		for i, nq := range r.quotas {
+			addRow(r.cluster, nq.ns, withScopes(nq.name, nq.q), nq.q, r.workloadFor(nq))
+
+			// After the last quota of a namespace with more than one quota, add its effective quota
+			lastInNS := i == len(r.quotas)-1 || r.quotas[i+1].ns != nq.ns
+			if lastInNS && i > 0 && r.quotas[i-1].ns == nq.ns {
+				var wq *quota.WorkloadQuota
+				if r.workloads != nil {
+					wq = r.workloads[nq.ns]
+				}
+				addRow(r.cluster, nq.ns, quota.EffectiveQuotaName, effective[nq.ns], wq)
+			}
+		}
+
+		kq, wq := r.total()
+		addRow(r.cluster, "Total", "", kq, wq)
+		allQuotas = append(allQuotas, kq)
+		if wq != nil {
+			allWorkloads = append(allWorkloads, wq)
+		}
+	}
+
+	if multi && len(results) > 1 {
+		var wq *quota.WorkloadQuota
+		if results[0].workloads != nil {
+			wq = quota.SumWorkloadQuotas(allWorkloads...)
+		}
+		addRow(allClustersName, "Total", "", quota.SumKubeQuotas(allQuotas...), wq)
+	}
+
+	tbl.Render()
+}
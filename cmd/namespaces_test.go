@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/quota"
+	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPreflightNamespaces(t *testing.T) {
+	namespaces := []string{"a", "b", "c"}
+	byNS := map[string][]*quota.KubeQuota{
+		"a": {quota.ForKubeQuota(testQuota("a", "q", "1", "1Gi", "2", "2Gi"))},
+		"b": {quota.ForKubeQuota(testQuota("b", "q", "1", "1Gi", "2", "2Gi"))},
+		"c": {quota.ForKubeQuota(testQuota("c", "q", "1", "1Gi", "2", "2Gi"))},
+	}
+	clusterChecks := len(namespaceWorkloadAccessChecks("", nil))
+
+	tests := []struct {
+		name    string
+		allowed func(ns string) bool
+		// wantReviews is the number of SelfSubjectAccessReviews that should be sent
+		wantReviews int
+		// wantMissing holds the namespaces that should be named as missing permissions
+		wantMissing []string
+	}{
+		{
+			name:        "allowed across the cluster",
+			allowed:     func(string) bool { return true },
+			wantReviews: clusterChecks,
+		},
+		{
+			name:        "allowed in every namespace",
+			allowed:     func(ns string) bool { return ns != "" },
+			wantReviews: clusterChecks * (1 + len(namespaces)),
+		},
+		{
+			name:        "denied in one namespace",
+			allowed:     func(ns string) bool { return ns != "" && ns != "b" },
+			wantReviews: clusterChecks * (1 + len(namespaces)),
+			wantMissing: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			reviews := 0
+			cs := fake.NewSimpleClientset()
+			cs.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				ssar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				ssar.Status.Allowed = tt.allowed(ssar.Spec.ResourceAttributes.Namespace)
+				mu.Lock()
+				reviews++
+				mu.Unlock()
+				return true, ssar, nil
+			})
+			c := &clusterClient{Client: kubernetes.NewClientForInterface(cs)}
+			cmd := &cobra.Command{Use: "workload"}
+			cmd.Flags().Bool("preflight", true, "")
+
+			err := preflightNamespaces(context.Background(), cmd, c, namespaces, byNS, 2)
+			if reviews != tt.wantReviews {
+				t.Errorf("sent %d access reviews, want %d", reviews, tt.wantReviews)
+			}
+			if tt.wantMissing == nil {
+				if err != nil {
+					t.Errorf("preflightNamespaces() error = %v", err)
+				}
+				return
+			}
+			var mae *kubernetes.MissingAccessError
+			if !errors.As(err, &mae) {
+				t.Fatalf("preflightNamespaces() error = %v, want missing access", err)
+			}
+			for _, check := range mae.Missing {
+				if !slices.Contains(tt.wantMissing, check.Namespace) {
+					t.Errorf("missing access in namespace %q, want only %v", check.Namespace, tt.wantMissing)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
-	"context"
+	"fmt"
 
 	"github.com/aauren/kube-quota/pkg/cli"
-	kubequota "github.com/aauren/kube-quota/pkg/kubernetes/quota"
+	"github.com/aauren/kube-quota/pkg/kubernetes"
 	"github.com/aauren/kube-quota/pkg/quota"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 )
 
@@ -14,8 +16,13 @@ import (
 var quotaCmd = &cobra.Command{
 	Use:   "quota",
 	Short: "See the quota",
-	Args:  cobra.MaximumNArgs(1),
-	Run:   quotaRun,
+	Long: `See the quota.
+
+Only the namespace's ResourceQuotas are shown and combined into its effective quota. On OpenShift, ClusterResourceQuotas that select
+the namespace are enforced as well, so the namespace may be limited more tightly than shown here. Use "kube-quota clusterquota -n
+<namespace>" to see them.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  quotaRun,
 }
 
 func init() {
@@ -24,6 +31,8 @@ func init() {
 	quotaCmd.Flags().StringP("namespace", "n", "", "namespace to search within")
 	quotaCmd.Flags().StringP("quota-name", "q", "", "specific name of the quota you want to search for (by default "+
 		"it will show a single quota within the requested namespace if there is only one found)")
+	quotaCmd.Flags().BoolP("all-namespaces", "A", false, "show every quota in every namespace along with a cluster wide total (a "+
+		"quota name limits this to quotas with that name)")
 }
 
 func quotaRun(cmd *cobra.Command, args []string) {
@@ -33,39 +42,146 @@ func quotaRun(cmd *cobra.Command, args []string) {
 	}
 
 	// Create our context and get any arguments the user may have set
-	ctx := context.Background()
+	ctx := cmd.Context()
 	ns := getFlagString(cmd, "namespace")
 	quotaName := ""
 	if len(args) > 0 {
 		quotaName = args[0]
 	}
 
-	// Get all of our data and format it.
-	kq, err := kubequota.FindByNSAndName(ctx, ns, quotaName)
+	clients, err := newClusterClients(cmd)
 	if err != nil {
-		klog.Fatalf("could not get pods by namespace: %v", err)
+		exitWithError("could not create kubernetes client", err)
 	}
-	q := quota.ForKubeQuota(kq)
 
-	// Setup our table and add our header.
-	tbl := cli.CreateTableWriter()
-	cli.AddTableHeader(tbl, []string{"Name"}, q)
+	if getFlagBool(cmd, "all-namespaces") {
+		results, err := forEachCluster(clients, func(c *clusterClient) (*allNamespacesResult, error) {
+			err := preflight(ctx, cmd, c.Client, kubernetes.QuotaAccessChecks(metav1.NamespaceAll, "")...)
+			if err != nil {
+				return nil, err
+			}
+			quotas, err := listAllQuotas(ctx, c, quotaName)
+			if err != nil {
+				return nil, err
+			}
+			return &allNamespacesResult{cluster: c.name, quotas: quotas}, nil
+		})
+		if err != nil {
+			exitWithError("could not get quota data", err)
+		}
+		renderAllNamespacesTable(cmd, results, isMultiCluster(clients))
+		return
+	}
 
-	// Add our data to the table.
-	err = cli.AddRow(tbl, q, []string{"Quota"})
+	// Get all of our data from every cluster that we were asked to look at
+	qs, err := forEachCluster(clients, func(c *clusterClient) ([]*quota.KubeQuota, error) {
+		err := preflight(ctx, cmd, c.Client, kubernetes.QuotaAccessChecks(ns, quotaName)...)
+		if err != nil {
+			return nil, err
+		}
+		rqs, err := c.FindQuotasByNSAndName(ctx, ns, quotaName)
+		if err != nil {
+			return nil, fmt.Errorf("could not get quota: %w", err)
+		}
+		return kubeQuotas(rqs), nil
+	})
 	if err != nil {
-		klog.Fatalf("Could not add row to table: %v", err)
+		exitWithError("could not get quota data", err)
+	}
+
+	// Setup our table and add our header.
+	multi := isMultiCluster(clients)
+	tbl := cli.CreateTableWriter(cmd.OutOrStdout())
+	headerers := make([]cli.TableHeaderer, 0, len(qs))
+	for _, kqs := range qs {
+		for _, q := range kqs {
+			headerers = append(headerers, q)
+		}
+	}
+	cli.AddTableHeader(tbl, withCluster(multi, clusterHeader, "Name"), headerers...)
+
+	// Add our data to the table, a namespace with more than one quota gets a row for each of them followed by the effective quota
+	effective := make([]*quota.KubeQuota, 0, len(qs))
+	for i, kqs := range qs {
+		for _, q := range kqs {
+			addQuotaStatusRows(tbl, clients[i].name, q, len(kqs) > 1, multi)
+		}
+		eq := effectiveQuota(kqs)
+		if len(kqs) > 1 {
+			err = cli.AddRow(tbl, eq, withCluster(multi, clients[i].name, quotaLabel("Quota", eq, true)))
+			if err != nil {
+				klog.Fatalf("Could not add effective row to table: %v", err)
+			}
+		}
+		effective = append(effective, eq)
+	}
+	if multi && len(qs) > 1 {
+		err = cli.AddRow(tbl, quota.SumKubeQuotas(effective...), withCluster(multi, allClustersName, "Total"))
+		if err != nil {
+			klog.Fatalf("Could not add total row to table: %v", err)
+		}
 	}
 
 	// Render our table
 	tbl.Render()
 }
 
-func quotaValidateInput(cmd *cobra.Command) error {
-	err := cmd.MarkFlagRequired("namespace")
+// addQuotaStatusRows adds the row for a quota, followed by how much of it is used and how much of it remains according to its status
+// when the API server has filled that in
+func addQuotaStatusRows(tbl *cli.TableWriterHeaderTracker, cluster string, kq *quota.KubeQuota, multiple, multi bool) {
+	err := cli.AddRow(tbl, kq, withCluster(multi, cluster, quotaLabel("Quota", kq, multiple)))
+	if err != nil {
+		klog.Fatalf("Could not add row to table: %v", err)
+	}
+	if !kq.HasStatus() {
+		return
+	}
+
+	err = cli.AddRow(tbl, &quota.QuotaStatus{KQ: kq}, withCluster(multi, cluster, quotaLabel("Used", kq, multiple)))
 	if err != nil {
-		return err
+		klog.Fatalf("Could not add used row to table: %v", err)
 	}
+	err = cli.AddRow(tbl, &quota.QuotaRemaining{KQ: kq}, withCluster(multi, cluster, quotaLabel("Remaining", kq, multiple)))
+	if err != nil {
+		klog.Fatalf("Could not add remaining row to table: %v", err)
+	}
+}
+
+// kubeQuotas converts every ResourceQuota into a KubeQuota
+func kubeQuotas(rqs []*v1.ResourceQuota) []*quota.KubeQuota {
+	kqs := make([]*quota.KubeQuota, 0, len(rqs))
+	for _, rq := range rqs {
+		kqs = append(kqs, quota.ForKubeQuota(rq))
+	}
+	return kqs
+}
+
+// effectiveQuota returns the quota that actually limits a namespace with the given quotas, which is the tightest limit of any of them
+// for each resource. When there is only a single quota without scopes, that quota is returned as it is.
+func effectiveQuota(kqs []*quota.KubeQuota) *quota.KubeQuota {
+	if len(kqs) == 1 && !kqs[0].Scoped() {
+		return kqs[0]
+	}
+	return quota.EffectiveKubeQuota(kqs...)
+}
+
+// quotaLabel returns the label of a row for a quota, which includes the quota's name when the namespace has more than one of them and
+// the quota's scopes when it has any
+func quotaLabel(label string, kq *quota.KubeQuota, multiple bool) string {
+	if multiple {
+		label = fmt.Sprintf("%s (%s)", label, kq.Name)
+	}
+	return withScopes(label, kq)
+}
 
-	return nil
+// withScopes appends the quota's scopes to a label, so that it is clear that the quota doesn't apply to every pod
+func withScopes(label string, kq *quota.KubeQuota) string {
+	if !kq.Scoped() {
+		return label
+	}
+	return fmt.Sprintf("%s [%s]", label, kq.ScopeString())
+}
+
+func quotaValidateInput(cmd *cobra.Command) error {
+	return validateNamespaceFlags(cmd)
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestQuotaCommand(t *testing.T) {
+	objs := []runtime.Object{
+		testQuota("team", "compute", "4", "8Gi", "8", "16Gi"),
+		testQuota("other", "compute", "1", "1Gi", "1", "1Gi"),
+	}
+
+	out := runKubeQuota(t, objs, "quota", "-n", "team")
+	assertRow(t, out, []string{"Quota"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"})
+}
+
+func TestQuotaCommandMultipleQuotas(t *testing.T) {
+	objs := []runtime.Object{
+		testQuota("team", "a", "4", "8Gi", "8", "16Gi"),
+		testQuota("team", "b", "2", "16Gi", "8", "8Gi"),
+	}
+
+	out := runKubeQuota(t, objs, "quota", "-n", "team")
+	assertRow(t, out, []string{"Quota (a)"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"})
+	assertRow(t, out, []string{"Quota (b)"}, []string{"2.0 Cores", "16.0 GB", "8.0 Cores", "8.0 GB"})
+	assertRow(t, out, []string{"Quota (effective)"}, []string{"2.0 Cores", "8.0 GB", "8.0 Cores", "8.0 GB"})
+}
+
+func TestQuotaCommandAllNamespaces(t *testing.T) {
+	objs := []runtime.Object{
+		testQuota("team", "compute", "4", "8Gi", "8", "16Gi"),
+		testQuota("other", "compute", "1", "1Gi", "1", "1Gi"),
+	}
+
+	out := runKubeQuota(t, objs, "quota", "-A")
+	assertRow(t, out, []string{"other", "compute"}, []string{"1.0 Cores", "1.0 GB", "1.0 Cores", "1.0 GB"})
+	assertRow(t, out, []string{"team", "compute"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"})
+	assertRow(t, out, []string{"Total", ""}, []string{"5.0 Cores", "9.0 GB", "9.0 Cores", "17.0 GB"})
+}
+
+func TestQuotaCommandMultipleClusters(t *testing.T) {
+	objs := []runtime.Object{testQuota("team", "compute", "4", "8Gi", "8", "16Gi")}
+
+	out := runKubeQuota(t, objs, "quota", "-n", "team", "--contexts", "east,west")
+	assertRow(t, out, []string{"east", "Quota"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"})
+	assertRow(t, out, []string{"west", "Quota"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"})
+	assertRow(t, out, []string{"All Clusters", "Total"}, []string{"8.0 Cores", "16.0 GB", "16.0 Cores", "32.0 GB"})
+}
@@ -16,11 +16,20 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	goflags "flag"
 
+	"github.com/aauren/kube-quota/pkg/kubernetes"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
 )
 
@@ -36,8 +45,13 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// Cancel the context of the running command when we're interrupted or terminated so that it can stop cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
+		stop()
 		os.Exit(1)
 	}
 }
@@ -46,6 +60,43 @@ func init() {
 	fs := goflags.NewFlagSet("", goflags.PanicOnError)
 	klog.InitFlags(fs)
 	rootCmd.Flags().AddGoFlagSet(fs)
+
+	rootCmd.PersistentFlags().String("kubeconfig", "", "path to the kubeconfig file to use (defaults to $KUBECONFIG or ~/.kube/config)")
+	rootCmd.PersistentFlags().String("context", "", "name of the kubeconfig context to use (defaults to the current context)")
+	rootCmd.PersistentFlags().String("cluster", "", "name of the kubeconfig cluster to use")
+	rootCmd.PersistentFlags().String("user", "", "name of the kubeconfig user to use")
+	rootCmd.PersistentFlags().Bool("in-cluster", false, "use the in-cluster service account configuration instead of a kubeconfig (this is "+
+		"detected automatically when running in a Pod without a kubeconfig)")
+	rootCmd.PersistentFlags().String("as", "", "username to impersonate for the operation")
+	rootCmd.PersistentFlags().StringArray("as-group", []string{}, "group to impersonate for the operation, can be repeated to specify "+
+		"multiple groups")
+	rootCmd.PersistentFlags().StringSlice("contexts", []string{}, "comma separated list of kubeconfig contexts to query concurrently, "+
+		"results are shown per cluster along with a cross-cluster total")
+	rootCmd.PersistentFlags().Bool("all-contexts", false, "query every context in the kubeconfig concurrently")
+	rootCmd.PersistentFlags().StringSliceP("filename", "f", []string{}, "read objects from JSON or YAML files or snapshots instead "+
+		"of querying a cluster (use - for stdin)")
+	rootCmd.PersistentFlags().Duration("request-timeout", 0, "how long to wait for a single request to the API server before giving up "+
+		"(e.g. 10s, 1m), 0 waits forever")
+	rootCmd.PersistentFlags().Float32("qps", 0, "maximum queries per second to the API server (defaults to the client-go default of 5)")
+	rootCmd.PersistentFlags().Int("burst", 0, "maximum burst of queries to the API server (defaults to the client-go default of 10)")
+	rootCmd.PersistentFlags().Bool("preflight", true, "check that all required RBAC permissions are present before querying the cluster")
+}
+
+func getConfigOptions(cmd *cobra.Command) *kubernetes.ConfigOptions {
+	return &kubernetes.ConfigOptions{
+		Kubeconfig: getFlagString(cmd, "kubeconfig"),
+		Context:    getFlagString(cmd, "context"),
+		Cluster:    getFlagString(cmd, "cluster"),
+		User:       getFlagString(cmd, "user"),
+		InCluster:  getFlagBool(cmd, "in-cluster"),
+
+		Impersonate:       getFlagString(cmd, "as"),
+		ImpersonateGroups: getFlagStringArray(cmd, "as-group"),
+
+		Timeout: getFlagDuration(cmd, "request-timeout"),
+		QPS:     getFlagFloat32(cmd, "qps"),
+		Burst:   getFlagInt(cmd, "burst"),
+	}
 }
 
 func getFlagString(cmd *cobra.Command, flagName string) string {
@@ -63,3 +114,64 @@ func getFlagBool(cmd *cobra.Command, flagName string) bool {
 	}
 	return val
 }
+
+func getFlagStringArray(cmd *cobra.Command, flagName string) []string {
+	val, err := cmd.Flags().GetStringArray(flagName)
+	if err != nil {
+		klog.Fatalf("Could not get string array flag: %s - %v", flagName, err)
+	}
+	return val
+}
+
+func getFlagStringSlice(cmd *cobra.Command, flagName string) []string {
+	val, err := cmd.Flags().GetStringSlice(flagName)
+	if err != nil {
+		klog.Fatalf("Could not get string slice flag: %s - %v", flagName, err)
+	}
+	return val
+}
+
+func getFlagDuration(cmd *cobra.Command, flagName string) time.Duration {
+	val, err := cmd.Flags().GetDuration(flagName)
+	if err != nil {
+		klog.Fatalf("Could not get duration flag: %s - %v", flagName, err)
+	}
+	return val
+}
+
+func getFlagFloat32(cmd *cobra.Command, flagName string) float32 {
+	val, err := cmd.Flags().GetFloat32(flagName)
+	if err != nil {
+		klog.Fatalf("Could not get float flag: %s - %v", flagName, err)
+	}
+	return val
+}
+
+func getFlagInt(cmd *cobra.Command, flagName string) int {
+	val, err := cmd.Flags().GetInt(flagName)
+	if err != nil {
+		klog.Fatalf("Could not get int flag: %s - %v", flagName, err)
+	}
+	return val
+}
+
+// exitWithError exits with msg and a clear description of err, see describeError
+func exitWithError(msg string, err error) {
+	klog.Exitf("%s: %s", msg, describeError(err))
+}
+
+// describeError describes err for the user. Interruptions and timeouts, which are expected when the API server is slow or unreachable,
+// are reported as such instead of with the raw error.
+func describeError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout(), apierrors.IsTimeout(err),
+		apierrors.IsServerTimeout(err):
+		return fmt.Sprintf("timed out waiting for the API server, it may be unreachable (the wait can be changed with --request-timeout): %v",
+			err)
+	}
+
+	return err.Error()
+}
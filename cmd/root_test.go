@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDescribeError(t *testing.T) {
+	const timedOut = "timed out waiting for the API server"
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "interrupted", err: fmt.Errorf("could not list pods: %w", context.Canceled), want: "interrupted"},
+		{name: "deadline", err: fmt.Errorf("could not list pods: %w", context.DeadlineExceeded), want: timedOut},
+		{name: "network timeout", err: &net.DNSError{Err: "i/o timeout", Name: "api.example.com", IsTimeout: true}, want: timedOut},
+		{name: "api timeout", err: apierrors.NewTimeoutError("request did not complete", 1), want: timedOut},
+		{name: "server timeout", err: apierrors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "list", 1), want: timedOut},
+		{name: "other network error", err: &net.DNSError{Err: "no such host", Name: "api.example.com", IsNotFound: true},
+			want: "lookup api.example.com: no such host"},
+		{name: "other", err: errors.New("forbidden"), want: "forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := describeError(tt.err)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("describeError() = %q, want it to start with %q", got, tt.want)
+			}
+			// Timeouts still include the error itself
+			if tt.want == timedOut && !strings.HasSuffix(got, tt.err.Error()) {
+				t.Errorf("describeError() = %q, want it to end with %q", got, tt.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/snapshot"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Capture everything kube-quota reads into an archive that other commands can replay with --filename",
+	Run:   snapshotRun,
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+
+	snapshotCmd.Flags().StringSliceP("namespace", "n", []string{}, "namespaces to capture, can be repeated or comma separated")
+	snapshotCmd.Flags().StringP("output", "o", "", "where to write the snapshot, a path ending in .tar.gz or .tgz writes a gzipped "+
+		"tarball otherwise a directory is written")
+	_ = snapshotCmd.MarkFlagRequired("namespace")
+	_ = snapshotCmd.MarkFlagRequired("output")
+}
+
+func snapshotRun(cmd *cobra.Command, _ []string) {
+	// Create our context and get any arguments the user may have set
+	ctx := cmd.Context()
+	namespaces := getFlagStringSlice(cmd, "namespace")
+	output := getFlagString(cmd, "output")
+	clients, err := newClusterClients(cmd)
+	if err != nil {
+		exitWithError("could not create kubernetes client", err)
+	}
+	if isMultiCluster(clients) {
+		klog.Exitf("a snapshot captures a single cluster, please select it with --context instead of --contexts or --all-contexts")
+	}
+	client := clients[0].Client
+
+	err = preflight(ctx, cmd, client, snapshot.AccessChecks(namespaces)...)
+	if err != nil {
+		exitWithError("could not capture snapshot", err)
+	}
+
+	// Capture all of the data and write it out
+	cluster := ""
+	if !client.Offline() {
+		cluster = kubernetes.ClusterName(getConfigOptions(cmd))
+	}
+	snap, err := snapshot.Capture(ctx, client, cluster, namespaces)
+	if err != nil {
+		exitWithError("could not capture snapshot", err)
+	}
+	err = snap.Write(output)
+	if err != nil {
+		klog.Fatalf("could not write snapshot to %s: %v", output, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote snapshot of %d namespace(s) to %s\n", len(namespaces), output)
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/quota"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// clearScreen moves the cursor to the top left of the terminal and clears it so that the table can be redrawn in place
+	clearScreen = "\033[H\033[2J"
+)
+
+// watchWorkload keeps the workload table for a namespace up to date by watching its pods and claims (and quota when it was asked for)
+// through shared informers. The table is redrawn in place every time something changes, until the context is cancelled.
+func watchWorkload(ctx context.Context, cmd *cobra.Command, c *clusterClient, o *workloadOptions) error {
+	resources := []string{"pods", "persistentvolumeclaims"}
+	if o.addQuota {
+		resources = append(resources, "resourcequotas")
+	}
+	err := preflight(ctx, cmd, c.Client, kubernetes.WatchAccessChecks(o.ns, resources...)...)
+	if err != nil {
+		return err
+	}
+
+	lrd, err := getLimitRangeDefaults(ctx, c, o.ns)
+	if err != nil {
+		return err
+	}
+
+	// changed is buffered so that a burst of events only results in a single redraw
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	// nq is updated by the informer's event handlers and read when rendering, so it is guarded by mu
+	var mu sync.Mutex
+	nq := quota.NewNamespaceWorkloadQuota(o.ns, true)
+	var terminated *quota.NamespaceWorkloadQuota
+	if o.showTerminated {
+		terminated = quota.NewNamespaceWorkloadQuota(o.ns, true)
+	}
+	updatePod := func(obj interface{}) {
+		if pod, ok := obj.(*v1.Pod); ok {
+			pod = lrd.Apply(pod)
+			mu.Lock()
+			// Pods move between the total and the terminated pods as they finish, so always remove them from the one they don't
+			// belong to
+			if o.skipPod(pod, time.Now()) {
+				nq.DeletePod(pod.Namespace, pod.Name)
+				if terminated != nil {
+					terminated.UpdatePod(pod)
+				}
+			} else {
+				nq.UpdatePod(pod)
+				if terminated != nil {
+					terminated.DeletePod(pod.Namespace, pod.Name)
+				}
+			}
+			mu.Unlock()
+			notify()
+		}
+	}
+
+	factory := c.NewInformerFactory(o.ns)
+	podLister := factory.Core().V1().Pods().Lister()
+	_, err = factory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    updatePod,
+		UpdateFunc: func(_, obj interface{}) { updatePod(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*v1.Pod); ok {
+				mu.Lock()
+				nq.DeletePod(pod.Namespace, pod.Name)
+				if terminated != nil {
+					terminated.DeletePod(pod.Namespace, pod.Name)
+				}
+				mu.Unlock()
+				notify()
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	updateClaim := func(obj interface{}) {
+		if pvc, ok := obj.(*v1.PersistentVolumeClaim); ok {
+			mu.Lock()
+			nq.UpdateClaim(pvc)
+			mu.Unlock()
+			notify()
+		}
+	}
+	_, err = factory.Core().V1().PersistentVolumeClaims().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    updateClaim,
+		UpdateFunc: func(_, obj interface{}) { updateClaim(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pvc, ok := obj.(*v1.PersistentVolumeClaim); ok {
+				mu.Lock()
+				nq.DeleteClaim(pvc.Namespace, pvc.Name)
+				mu.Unlock()
+				notify()
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Objects other than pods and claims are only counted again when a quota changes. The quota controller updates a quota's status
+	// whenever an object that it counts is created or deleted, so this keeps the counts current without watching every kind of object.
+	objectsStale := true
+	quotaChanged := func() {
+		mu.Lock()
+		objectsStale = true
+		mu.Unlock()
+		notify()
+	}
+
+	var quotaLister corev1listers.ResourceQuotaLister
+	if o.addQuota {
+		quotaInformer := factory.Core().V1().ResourceQuotas()
+		_, err = quotaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { quotaChanged() },
+			UpdateFunc: func(_, _ interface{}) { quotaChanged() },
+			DeleteFunc: func(interface{}) { quotaChanged() },
+		})
+		if err != nil {
+			return err
+		}
+		quotaLister = quotaInformer.Lister()
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			if ctx.Err() != nil {
+				// We were interrupted before the caches were synced, which is just as expected as being interrupted afterwards
+				return nil
+			}
+			return fmt.Errorf("could not sync informer for %v", informerType)
+		}
+	}
+	notify()
+
+	// objects holds the object counts from the last time that they were counted, it is only used by the loop below so it isn't guarded
+	var objects map[v1.ResourceName]int64
+	var previous *quota.WorkloadQuota
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+
+		// Only a copy of the totals is taken while holding the lock, so that the informers' event handlers are never held up by the
+		// API calls and the work below
+		mu.Lock()
+		snapshot := nq.Snapshot()
+		var terminatedSnapshot *quota.NamespaceWorkloadQuota
+		if terminated != nil {
+			terminatedSnapshot = terminated.Snapshot()
+		}
+		countObjectsAgain := objectsStale
+		objectsStale = false
+		mu.Unlock()
+
+		r := workloadResult{
+			cluster:    c.name,
+			nq:         snapshot,
+			terminated: terminatedSnapshot,
+			previous:   previous,
+		}
+		ro := *o
+		var quotaErr, countErr error
+		if o.addQuota {
+			var rqs []*v1.ResourceQuota
+			rqs, quotaErr = findQuotasInLister(quotaLister, o.ns, o.quotaName)
+			if quotaErr == nil {
+				r.quotas = kubeQuotas(rqs)
+				r.q = effectiveQuota(r.quotas)
+				r.scoped, quotaErr = scopedUsageFromLister(podLister, o, r.quotas, lrd)
+			}
+			if quotaErr != nil {
+				// Without a quota there is nothing to compare against, so leave the quota rows out until one shows up
+				ro.addQuota, ro.showUsage = false, false
+			} else if countObjectsAgain {
+				var counts map[v1.ResourceName]int64
+				counts, countErr = countObjects(ctx, c, o.ns, r.quotas)
+				if countErr == nil {
+					objects = counts
+				}
+			}
+			if countErr != nil || (quotaErr != nil && countObjectsAgain) {
+				// Try again on the next redraw
+				mu.Lock()
+				objectsStale = true
+				mu.Unlock()
+			}
+		}
+		for name, count := range objects {
+			snapshot.SetObjectCount(name, count)
+		}
+		r.wq = snapshot.Sum()
+
+		var buf bytes.Buffer
+		renderWorkloadTable(&buf, []*workloadResult{&r}, false, &ro)
+		previous = r.wq
+
+		out := cmd.OutOrStdout()
+		fmt.Fprint(out, clearScreen)
+		fmt.Fprintf(out, "Watching namespace %s, last updated %s (changes since the previous update are shown in brackets)\n",
+			o.ns, time.Now().Format(time.TimeOnly))
+		if quotaErr != nil {
+			fmt.Fprintf(out, "Could not find quota: %v\n", quotaErr)
+		}
+		if countErr != nil {
+			fmt.Fprintf(out, "Could not count objects: %v\n", countErr)
+		}
+		fmt.Fprint(out, buf.String())
+	}
+}
+
+// scopedUsageFromLister adds up the usage of every quota with scopes from the pods in an informer's cache. Scopes are only known once the
+// quotas are, so unlike the namespace total this is worked out again every time the table is rendered.
+func scopedUsageFromLister(lister corev1listers.PodLister, o *workloadOptions, kqs []*quota.KubeQuota,
+	lrd *quota.LimitRangeDefaults) (*quota.ScopedUsage, error) {
+	scoped := quota.NewScopedUsage(o.ns, kqs)
+	if !slices.ContainsFunc(kqs, (*quota.KubeQuota).Scoped) {
+		return scoped, nil
+	}
+
+	pods, err := lister.Pods(o.ns).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, pod := range pods {
+		if pod = lrd.Apply(pod); !o.skipPod(pod, now) {
+			scoped.AddPod(pod, quota.QuotaForPod(pod))
+		}
+	}
+	return scoped, nil
+}
+
+// findQuotasInLister finds quotas the same way that Client.FindQuotasByNSAndName does, but from an informer's cache
+func findQuotasInLister(lister corev1listers.ResourceQuotaLister, ns, name string) ([]*v1.ResourceQuota, error) {
+	if name != "" {
+		rq, err := lister.ResourceQuotas(ns).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return []*v1.ResourceQuota{rq}, nil
+	}
+
+	quotas, err := lister.ResourceQuotas(ns).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.SelectQuotas(ns, quotas)
+}
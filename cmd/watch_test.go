@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// frameBuffer holds the output of a watch, which is written while the test reads it
+type frameBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *frameBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lastFrame returns everything that was drawn since the screen was last cleared, without colors
+func (b *frameBuffer) lastFrame() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	frames := strings.Split(b.buf.String(), clearScreen)
+	return colorEscape.ReplaceAllString(frames[len(frames)-1], "")
+}
+
+// startWatch runs kube-quota with the given arguments against the fake clientset until the test ends
+func startWatch(t *testing.T, args ...string) *frameBuffer {
+	t.Helper()
+
+	out := &frameBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	// Cobra only hands its context to a subcommand that doesn't have one yet, which it still does from any earlier run, so the
+	// subcommand's context is replaced and then put back to the one that every other test runs with
+	sub, _, err := rootCmd.Find(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.SetContext(ctx)
+	done := make(chan error)
+	rootCmd.SetOut(out)
+	rootCmd.SetArgs(args)
+	go func() {
+		done <- rootCmd.ExecuteContext(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("kube-quota %s: %v", strings.Join(args, " "), err)
+		}
+		sub.SetContext(context.Background())
+	})
+	return out
+}
+
+// waitForFrame waits for a frame to be drawn that matches, and returns it
+func waitForFrame(t *testing.T, out *frameBuffer, desc string, matches func(frame string) bool) string {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		frame := out.lastFrame()
+		if matches(frame) {
+			return frame
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, last frame:\n%s", desc, frame)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// rowIs returns a frame matcher for a table row with the given leading cells followed by want
+func rowIs(prefix, want []string) func(string) bool {
+	return func(frame string) bool {
+		row := tableRow(frame, prefix...)
+		return row != nil && strings.Join(row[len(prefix):], "|") == strings.Join(want, "|")
+	}
+}
+
+func setPodPhase(t *testing.T, cs *fake.Clientset, ns, name string, phase v1.PodPhase) {
+	t.Helper()
+	pod, err := cs.CoreV1().Pods(ns).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod.Status.Phase = phase
+	_, err = cs.CoreV1().Pods(ns).UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchWorkloadTerminatedPods(t *testing.T) {
+	cs := useFakeClientset(t, []runtime.Object{
+		testPod("team", "web", "1", "1Gi", "2", "2Gi"),
+		testPod("team", "job", "500m", "1Gi", "1", "1Gi"),
+	})
+	out := startWatch(t, "workload", "-n", "team", "--watch", "--show-terminated")
+
+	waitForFrame(t, out, "the first frame", rowIs([]string{"Total"}, []string{"1.5 Cores", "2.0 GB", "3.0 Cores", "3.0 GB"}))
+
+	// The finished pod moves from the total to the terminated pods, and the total shows how much it changed by
+	setPodPhase(t, cs, "team", "job", v1.PodSucceeded)
+	frame := waitForFrame(t, out, "the pod to finish", rowIs([]string{"Terminated"},
+		[]string{"500 Millicores", "1.0 GB", "1.0 Cores", "1.0 GB"}))
+	assertRow(t, frame, []string{"Total"}, []string{"1.0 Cores [-500 Millicores]", "1.0 GB [-1.0 GB]", "2.0 Cores [-1.0 Cores]",
+		"2.0 GB [-1.0 GB]"})
+
+	// Deleting the finished pod removes it from the terminated pods, while the total doesn't change any more
+	err := cs.CoreV1().Pods("team").Delete(context.Background(), "job", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame = waitForFrame(t, out, "the pod to be deleted", rowIs([]string{"Terminated"},
+		[]string{"0 Millicores", "0 B", "0 Millicores", "0 B"}))
+	assertRow(t, frame, []string{"Total"}, []string{"1.0 Cores", "1.0 GB", "2.0 Cores", "2.0 GB"})
+}
+
+func TestWatchWorkloadObjectCounts(t *testing.T) {
+	rq := testQuota("team", "compute", "4", "8Gi", "8", "16Gi")
+	rq.Spec.Hard[v1.ResourceName("count/services")] = resource.MustParse("5")
+	cs := useFakeClientset(t, []runtime.Object{
+		testPod("team", "web", "1", "1Gi", "2", "2Gi"),
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team"}},
+		rq,
+	})
+	out := startWatch(t, "workload", "-n", "team", "--watch", "--add-quota")
+
+	waitForFrame(t, out, "the first frame", rowIs([]string{"Total"}, []string{"1.0 Cores", "1.0 GB", "2.0 Cores", "2.0 GB", "1"}))
+	assertRow(t, out.lastFrame(), []string{"Quota"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB", "5"})
+
+	// Services aren't watched, so a new one isn't counted when a pod changes
+	_, err := cs.CoreV1().Services("team").Create(context.Background(),
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team"}}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cs.CoreV1().Pods("team").Create(context.Background(), testPod("team", "api", "1", "1Gi", "2", "2Gi"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForFrame(t, out, "the new pod", rowIs([]string{"Total"}, []string{"2.0 Cores [+1.0 Cores]", "2.0 GB [+1.0 GB]",
+		"4.0 Cores [+2.0 Cores]", "4.0 GB [+2.0 GB]", "1"}))
+
+	// Once the quota controller updates the quota's status, the objects are counted again
+	rq.Status.Used = v1.ResourceList{v1.ResourceName("count/services"): resource.MustParse("2")}
+	_, err = cs.CoreV1().ResourceQuotas("team").UpdateStatus(context.Background(), rq, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForFrame(t, out, "the services to be counted again", rowIs([]string{"Total"}, []string{"2.0 Cores", "2.0 GB", "4.0 Cores",
+		"4.0 GB", "2 [+1]"}))
+}
@@ -5,21 +5,38 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/aauren/kube-quota/pkg/cli"
-	kubequota "github.com/aauren/kube-quota/pkg/kubernetes/quota"
-	"github.com/aauren/kube-quota/pkg/kubernetes/workloads"
+	"github.com/aauren/kube-quota/pkg/kubernetes"
 	"github.com/aauren/kube-quota/pkg/quota"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/klog/v2"
 )
 
+const (
+	groupByOwner     = "owner"
+	groupByPod       = "pod"
+	groupByContainer = "container"
+	groupByNone      = "none"
+)
+
 // workloadCmd represents the quotaUsed command
 var workloadCmd = &cobra.Command{
 	Use:   "workload",
 	Short: "See the quota usage by workload",
-	Run:   workloadRun,
+	Long: `See the quota usage by workload.
+
+The usage is compared against the namespace's ResourceQuotas only. On OpenShift, ClusterResourceQuotas that select the namespace are
+enforced as well, so the namespace may be limited more tightly than shown here. Use "kube-quota clusterquota -n <namespace>" to see
+them.`,
+	Run: workloadRun,
 }
 
 func init() {
@@ -29,7 +46,23 @@ func init() {
 	workloadCmd.Flags().StringP("namespace", "n", "", "namespace to search within")
 	workloadCmd.Flags().StringP("quota-name", "q", "", "specific name of the quota you want to search for (by default it will show a "+
 		"single quota within the requested namespace if there is only one found)")
-	workloadCmd.Flags().BoolP("show-usage", "u", false, "show the usage against the current quota (enables add-quota as well)")
+	workloadCmd.Flags().BoolP("show-usage", "u", false, "show the usage against the current quota, along with the "+
+		"usage from the quota's status and any resources where the two disagree (enables add-quota as well)")
+	workloadCmd.Flags().BoolP("watch", "w", false, "keep watching pods and quotas and redraw the table whenever they change, showing how "+
+		"each value changed since the last redraw")
+	workloadCmd.Flags().StringP("group-by", "g", groupByNone, "break the total down into one row per group: owner (the top level "+
+		"controller such as a Deployment or CronJob), pod, container, or none (with --show-usage each row shows its share of the quota)")
+	workloadCmd.Flags().Bool("include-terminated", false, "count pods that have Succeeded or Failed, or that are past their deletion "+
+		"grace period, in the total (by default they are left out, the same way that quota admission leaves them out)")
+	workloadCmd.Flags().Bool("show-terminated", false, "show pods that have Succeeded or Failed, or that are past their deletion grace "+
+		"period, in a separate Terminated row that isn't counted in the total")
+	workloadCmd.Flags().StringP("selector", "l", "", "label selector (e.g. app=checkout) for a subset of pods that is shown in its "+
+		"own row next to the namespace total, --group-by breaks down this subset rather than the whole namespace")
+	workloadCmd.Flags().String("field-selector", "", "field selector (e.g. spec.nodeName=node1) for a subset of pods, this works the "+
+		"same way as --selector and can be combined with it")
+	workloadCmd.Flags().BoolP("all-namespaces", "A", false, "show the usage of every quota in every namespace along with a cluster "+
+		"wide total, each namespace's pods are compared against each of its quotas")
+	workloadCmd.Flags().Int("concurrency", defaultNamespaceConcurrency, "number of namespaces to query at once with --all-namespaces")
 }
 
 func workloadRun(cmd *cobra.Command, _ []string) {
@@ -39,68 +72,560 @@ func workloadRun(cmd *cobra.Command, _ []string) {
 	}
 
 	// Create our context and get any arguments the user may have set
-	ctx := context.Background()
-	ns := getFlagString(cmd, "namespace")
-	aq := getFlagBool(cmd, "add-quota")
-	qn := getFlagString(cmd, "quota-name")
-	us := getFlagBool(cmd, "show-usage")
+	ctx := cmd.Context()
+	o := workloadOptions{
+		ns:        getFlagString(cmd, "namespace"),
+		quotaName: getFlagString(cmd, "quota-name"),
+		showUsage: getFlagBool(cmd, "show-usage"),
+		groupBy:   getFlagString(cmd, "group-by"),
 
-	// Get all of our data and format it.
-	pl, err := workloads.GetPodsByNamespace(ctx, ns)
+		includeTerminated: getFlagBool(cmd, "include-terminated"),
+		showTerminated:    getFlagBool(cmd, "show-terminated"),
+		selector: kubernetes.PodSelector{
+			Labels: getFlagString(cmd, "selector"),
+			Fields: getFlagString(cmd, "field-selector"),
+		},
+	}
+	// show-usage needs the quota to compare against, so it implies add-quota
+	o.addQuota = getFlagBool(cmd, "add-quota") || o.showUsage
+	clients, err := newClusterClients(cmd)
 	if err != nil {
-		klog.Fatalf("could not get pods by namespace: %v", err)
+		exitWithError("could not create kubernetes client", err)
 	}
-	nq := quota.QuotaForPodList(pl)
-	wq := nq.Sum()
 
-	var q *quota.KubeQuota
-	if aq {
-		kq, err := kubequota.FindByNSAndName(ctx, ns, qn)
+	if getFlagBool(cmd, "all-namespaces") {
+		concurrency := getFlagInt(cmd, "concurrency")
+		results, err := forEachCluster(clients, func(c *clusterClient) (*allNamespacesResult, error) {
+			return getAllNamespacesWorkload(ctx, cmd, c, &o, concurrency)
+		})
 		if err != nil {
-			klog.Fatalf("could not get pods by namespace: %v", err)
+			exitWithError("could not get workload data", err)
 		}
-		q = quota.ForKubeQuota(kq)
+		renderAllNamespacesTable(cmd, results, isMultiCluster(clients))
+		return
 	}
 
-	// Setup our table and add our header.
-	tbl := cli.CreateTableWriter()
-	if aq || us {
-		cli.AddTableHeader(tbl, []string{"Name"}, q, wq)
-	} else {
-		cli.AddTableHeader(tbl, []string{"Name"}, wq)
+	if getFlagBool(cmd, "watch") {
+		if isMultiCluster(clients) {
+			klog.Exitf("--watch can only be used against a single cluster")
+		}
+		err = watchWorkload(ctx, cmd, clients[0], &o)
+		if err != nil {
+			exitWithError("could not watch workload data", err)
+		}
+		return
 	}
 
-	// Add our data to the table.
-	err = cli.AddRow(tbl, wq, []string{"Total"})
+	// Get all of our data from every cluster that we were asked to look at
+	results, err := forEachCluster(clients, func(c *clusterClient) (*workloadResult, error) {
+		return getWorkloadResult(ctx, cmd, c, &o)
+	})
 	if err != nil {
-		klog.Fatalf("Could not add data row to table: %v", err)
+		exitWithError("could not get workload data", err)
+	}
+
+	multi := isMultiCluster(clients)
+	if multi && len(results) > 1 {
+		results = append(results, sumWorkloadResults(results, &o))
+	}
+	renderWorkloadTable(cmd.OutOrStdout(), results, multi, &o)
+}
+
+// workloadOptions holds the user's choices for the workload command
+type workloadOptions struct {
+	ns        string
+	quotaName string
+	addQuota  bool
+	showUsage bool
+	groupBy   string
+	// includeTerminated counts terminal pods in the total, while showTerminated adds them up in a row of their own instead
+	includeTerminated bool
+	showTerminated    bool
+	// selector picks out a subset of the namespace's pods that is added up in a row of its own
+	selector kubernetes.PodSelector
+}
+
+// skipPod returns true if the pod shouldn't be counted in the total because quota admission no longer charges for it
+func (o *workloadOptions) skipPod(pod *v1.Pod, now time.Time) bool {
+	return !o.includeTerminated && quota.IsTerminalPod(pod, now)
+}
+
+// workloadResult holds everything that the workload command gathered from a single cluster
+type workloadResult struct {
+	cluster string
+	nq      *quota.NamespaceWorkloadQuota
+	wq      *quota.WorkloadQuota
+	// quotas holds every quota that was found, while q holds the effective quota that the workload is compared against
+	quotas []*quota.KubeQuota
+	q      *quota.KubeQuota
+	// scoped holds the usage of every quota with scopes, which only charge for some of the pods, it is nil when there are no quotas
+	scoped *quota.ScopedUsage
+	// selected holds the pods that matched --selector and --field-selector, it is nil when neither was given
+	selected *quota.NamespaceWorkloadQuota
+	// terminated holds the terminal pods that were left out of the total, it is nil unless --show-terminated was given
+	terminated *quota.NamespaceWorkloadQuota
+	// groups holds the breakdown of the total that was asked for with --group-by, it is nil when no breakdown was asked for
+	groups *quota.GroupedWorkloadQuota
+	// previous holds the totals from the last time that the result was rendered, if there was one, so that changes can be shown
+	previous *quota.WorkloadQuota
+}
+
+func getWorkloadResult(ctx context.Context, cmd *cobra.Command, c *clusterClient, o *workloadOptions) (*workloadResult, error) {
+	checks := kubernetes.PodAccessChecks(o.ns)
+	checks = append(checks, kubernetes.ClaimAccessChecks(o.ns)...)
+	if o.addQuota {
+		checks = append(checks, kubernetes.QuotaAccessChecks(o.ns, o.quotaName)...)
+	}
+	if o.groupBy == groupByOwner {
+		checks = append(checks, kubernetes.OwnerAccessChecks(o.ns)...)
+	}
+	err := preflight(ctx, cmd, c.Client, checks...)
+	if err != nil {
+		return nil, err
+	}
+	lrd, err := getLimitRangeDefaults(ctx, c, o.ns)
+	if err != nil {
+		return nil, err
 	}
-	if aq || us {
-		err = cli.AddRow(tbl, q, []string{"Quota"})
+
+	var owners *kubernetes.OwnerResolver
+	if o.groupBy == groupByOwner {
+		owners, err = c.NewOwnerResolver(ctx, o.ns)
 		if err != nil {
-			klog.Fatalf("Could not add quota row to table: %v", err)
+			return nil, fmt.Errorf("could not resolve pod owners: %w", err)
 		}
 	}
-	if us {
-		qu := quota.QuotaUsage{
-			KQ:  q,
-			NWQ: nq,
+
+	// Pods are summed page by page as they are listed so that large namespaces don't need to be held in memory
+	r := workloadResult{
+		cluster: c.name,
+		nq:      quota.NewNamespaceWorkloadQuota(o.ns, false),
+	}
+
+	// Quotas are found first so that each pod can be added to the quotas with scopes that it matches while it is listed
+	if o.addQuota {
+		rqs, err := c.FindQuotasByNSAndName(ctx, o.ns, o.quotaName)
+		if err != nil {
+			return nil, fmt.Errorf("could not get quota: %w", err)
 		}
-		err = cli.AddRow(tbl, &qu, []string{"Usage"})
+		r.quotas = kubeQuotas(rqs)
+		r.q = effectiveQuota(r.quotas)
+		r.scoped = quota.NewScopedUsage(o.ns, r.quotas)
+
+		// Which objects need to be counted is only known once the quotas are
+		err = preflight(ctx, cmd, c.Client, objectAccessChecks(o.ns, r.quotas)...)
 		if err != nil {
-			klog.Fatalf("Could not add usage row to table: %v", err)
+			return nil, err
+		}
+	}
+	if o.groupBy != groupByNone {
+		r.groups = quota.NewGroupedWorkloadQuota()
+	}
+	if o.showTerminated {
+		r.terminated = quota.NewNamespaceWorkloadQuota(o.ns, false)
+	}
+	now := time.Now()
+	err = c.ForEachPod(ctx, o.ns, kubernetes.PodSelector{}, func(pod *v1.Pod) error {
+		pod = lrd.Apply(pod)
+		if o.skipPod(pod, now) {
+			if r.terminated != nil {
+				r.terminated.AddPod(pod)
+			}
+			return nil
+		}
+
+		pq := quota.QuotaForPod(pod)
+		r.nq.AddPodQuota(pq)
+		if r.scoped != nil {
+			r.scoped.AddPod(pod, pq)
+		}
+		if o.selector.IsEmpty() {
+			addToGroups(r.groups, o.groupBy, owners, pod, pq)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get pods by namespace: %w", err)
+	}
+	err = addClaims(ctx, c, r.nq)
+	if err != nil {
+		return nil, err
+	}
+	err = addObjectCounts(ctx, c, r.nq, r.quotas)
+	if err != nil {
+		return nil, err
+	}
+	r.wq = r.nq.Sum()
+
+	// The selected subset is listed separately so that the API server does the filtering, the namespace total above still needs every
+	// pod in the namespace
+	if !o.selector.IsEmpty() {
+		r.selected = quota.NewNamespaceWorkloadQuota(o.ns, false)
+		err = c.ForEachPod(ctx, o.ns, o.selector, func(pod *v1.Pod) error {
+			if o.skipPod(pod, now) {
+				return nil
+			}
+			pq := quota.QuotaForPod(lrd.Apply(pod))
+			r.selected.AddPodQuota(pq)
+			addToGroups(r.groups, o.groupBy, owners, pod, pq)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not get pods matching %s: %w", o.selector, err)
+		}
+	}
+
+	return &r, nil
+}
+
+// getLimitRangeDefaults returns the defaults that the namespace's LimitRanges give to containers. Pods served by an API server have already
+// been through admission and had the defaults applied, so they are only needed for pods that were read from files, and nil is returned
+// for every other client.
+func getLimitRangeDefaults(ctx context.Context, c *clusterClient, ns string) (*quota.LimitRangeDefaults, error) {
+	if !c.Offline() {
+		return nil, nil
+	}
+	lrl, err := c.ListLimitRangesByNS(ctx, ns)
+	if err != nil {
+		return nil, fmt.Errorf("could not get limit ranges by namespace: %w", err)
+	}
+	return quota.NewLimitRangeDefaults(lrl.Items), nil
+}
+
+// addClaims adds the storage of every PersistentVolumeClaim in the namespace to its total
+func addClaims(ctx context.Context, c *clusterClient, nq *quota.NamespaceWorkloadQuota) error {
+	pvcl, err := c.ListClaimsByNS(ctx, nq.Namespace)
+	if err != nil {
+		return fmt.Errorf("could not get persistent volume claims by namespace: %w", err)
+	}
+	for idx := range pvcl.Items {
+		nq.AddClaim(&pvcl.Items[idx])
+	}
+	return nil
+}
+
+// addObjectCounts counts the objects that any of the quotas limit the number of, and sets their counts on the namespace
+func addObjectCounts(ctx context.Context, c *clusterClient, nq *quota.NamespaceWorkloadQuota, kqs []*quota.KubeQuota) error {
+	counts, err := countObjects(ctx, c, nq.Namespace, kqs)
+	if err != nil {
+		return err
+	}
+	for name, count := range counts {
+		nq.SetObjectCount(name, count)
+	}
+	return nil
+}
+
+// countObjects counts the objects in a namespace that any of the quotas limit the number of. Pods are counted as they are added and the
+// service counts are worked out from the namespace's services, everything else is counted through the dynamic client.
+func countObjects(ctx context.Context, c *clusterClient, ns string, kqs []*quota.KubeQuota) (map[v1.ResourceName]int64, error) {
+	counts := make(map[v1.ResourceName]int64)
+	var services map[v1.ResourceName]int64
+	for _, name := range quota.ObjectCountNames(kqs...) {
+		switch {
+		case name == v1.ResourcePods:
+			continue
+		case quota.IsServiceCount(name):
+			if services == nil {
+				svcl, err := c.ListServicesByNS(ctx, ns)
+				if err != nil {
+					return nil, fmt.Errorf("could not get services by namespace: %w", err)
+				}
+				services = quota.ServiceCounts(svcl.Items)
+			}
+			counts[name] = services[name]
+		default:
+			count, err := c.CountObjects(ctx, ns, quota.ObjectCountResource(name))
+			if meta.IsNoMatchError(err) {
+				// The quota counts a resource that the API server doesn't serve (or that can't be read from files)
+				klog.Warningf("Could not count %s in namespace %s: %v", name, ns, err)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not count %s: %w", name, err)
+			}
+			counts[name] = count
+		}
+	}
+	return counts, nil
+}
+
+// objectAccessChecks returns the permissions that addObjectCounts needs for the quotas
+func objectAccessChecks(ns string, kqs []*quota.KubeQuota) []kubernetes.AccessCheck {
+	checks := make([]kubernetes.AccessCheck, 0)
+	services := false
+	for _, name := range quota.ObjectCountNames(kqs...) {
+		switch {
+		case name == v1.ResourcePods:
+		case quota.IsServiceCount(name):
+			if !services {
+				checks = append(checks, kubernetes.ServiceAccessChecks(ns)...)
+				services = true
+			}
+		default:
+			checks = append(checks, kubernetes.ObjectAccessChecks(ns, quota.ObjectCountResource(name))...)
+		}
+	}
+	return checks
+}
+
+// addToGroups adds the pod to the groups that were asked for with --group-by
+func addToGroups(groups *quota.GroupedWorkloadQuota, groupBy string, owners *kubernetes.OwnerResolver, pod *v1.Pod,
+	pq *quota.PodQuota) {
+	switch groupBy {
+	case groupByOwner:
+		groups.Add(pq.Sum(), owners.Resolve(pod).String())
+	case groupByPod:
+		groups.Add(pq.Sum(), pq.Namespace, pq.Name)
+	case groupByContainer:
+		for _, wq := range pq.WorkloadQuotas {
+			groups.Add(wq, pq.Namespace, pq.Name, wq.Name)
 		}
 	}
+}
+
+// sumWorkloadResults combines the results of every cluster into a single cross-cluster result
+func sumWorkloadResults(results []*workloadResult, o *workloadOptions) *workloadResult {
+	nqs := make([]*quota.NamespaceWorkloadQuota, 0, len(results))
+	kqs := make([]*quota.KubeQuota, 0, len(results))
+	tqs := make([]*quota.NamespaceWorkloadQuota, 0, len(results))
+	sqs := make([]*quota.NamespaceWorkloadQuota, 0, len(results))
+	for _, r := range results {
+		if r.selected != nil {
+			sqs = append(sqs, r.selected)
+		}
+		nqs = append(nqs, r.nq)
+		if o.addQuota {
+			kqs = append(kqs, r.q)
+		}
+		if o.showTerminated {
+			tqs = append(tqs, r.terminated)
+		}
+	}
+
+	total := workloadResult{
+		cluster: allClustersName,
+		nq:      quota.MergeNamespaceWorkloadQuotas(nqs...),
+	}
+	total.wq = total.nq.Sum()
+	if o.addQuota {
+		total.q = quota.SumKubeQuotas(kqs...)
+	}
+	if o.showTerminated {
+		total.terminated = quota.MergeNamespaceWorkloadQuotas(tqs...)
+	}
+	if !o.selector.IsEmpty() {
+		total.selected = quota.MergeNamespaceWorkloadQuotas(sqs...)
+	}
+
+	return &total
+}
+
+func renderWorkloadTable(out io.Writer, results []*workloadResult, multi bool, o *workloadOptions) {
+	// Setup our table and add our header.
+	tbl := cli.CreateTableWriter(out)
+	headerers := make([]cli.TableHeaderer, 0)
+	for _, r := range results {
+		headerers = append(headerers, r.wq)
+		if o.addQuota {
+			headerers = append(headerers, r.q)
+			for _, kq := range r.quotas {
+				headerers = append(headerers, kq)
+			}
+		}
+	}
+	cli.AddTableHeader(tbl, withCluster(multi, clusterHeader, groupHeaders(o.groupBy)...), headerers...)
+
+	// Add our data to the table.
+	for _, r := range results {
+		addWorkloadRows(tbl, r, multi, o)
+	}
 
 	// Render our table
 	tbl.Render()
 }
 
+// groupHeaders returns the prefix columns that identify each row of the table for the given --group-by. Drill downs into pods and
+// containers get a column per level, while everything else is identified by a single name.
+func groupHeaders(groupBy string) []string {
+	switch groupBy {
+	case groupByPod:
+		return []string{"Namespace", "Pod"}
+	case groupByContainer:
+		return []string{"Namespace", "Pod", "Container"}
+	}
+	return []string{"Name"}
+}
+
+// labelPrefix returns the prefixes for a summary row (like Total or Quota) in a table with the given --group-by. The label goes in the
+// first prefix column and any further prefix columns are left empty.
+func labelPrefix(groupBy, label string) []string {
+	prefix := make([]string, len(groupHeaders(groupBy)))
+	prefix[0] = label
+	return prefix
+}
+
+func addWorkloadRows(tbl *cli.TableWriterHeaderTracker, r *workloadResult, multi bool, o *workloadOptions) {
+	if r.groups != nil {
+		for _, keys := range r.groups.Keys() {
+			// When showing usage, show each group's share of the quota as well
+			var group cli.HeaderValuer = r.groups.Get(keys...)
+			if o.showUsage {
+				group = &quota.WorkloadUsage{
+					KQ: r.q,
+					WQ: r.groups.Get(keys...),
+				}
+			}
+			err := cli.AddRow(tbl, group, withCluster(multi, r.cluster, keys...))
+			if err != nil {
+				klog.Fatalf("Could not add group row to table: %v", err)
+			}
+		}
+	}
+
+	if r.selected != nil {
+		// When showing usage, show the subset's share of the quota as well
+		var selected cli.HeaderValuer = r.selected.Sum()
+		if o.showUsage {
+			selected = &quota.WorkloadUsage{
+				KQ: r.q,
+				WQ: r.selected.Sum(),
+			}
+		}
+		err := cli.AddRow(tbl, selected, withCluster(multi, r.cluster, labelPrefix(o.groupBy, o.selector.String())...))
+		if err != nil {
+			klog.Fatalf("Could not add selected row to table: %v", err)
+		}
+	}
+
+	var total cli.HeaderValuer = r.wq
+	if r.previous != nil {
+		total = quota.NewDelta(r.wq, r.previous, r.wq)
+	}
+	err := cli.AddRow(tbl, total, withCluster(multi, r.cluster, labelPrefix(o.groupBy, "Total")...))
+	if err != nil {
+		klog.Fatalf("Could not add data row to table: %v", err)
+	}
+	if o.addQuota {
+		// A namespace with more than one quota gets rows for each of them, followed by the effective quota that is made up of the
+		// tightest limit of each one
+		kqs := []*quota.KubeQuota{r.q}
+		switch {
+		case len(r.quotas) > 1:
+			kqs = append(append([]*quota.KubeQuota{}, r.quotas...), r.q)
+		case len(r.quotas) == 1:
+			// A lone quota with scopes has an empty effective quota, so show the quota itself instead
+			kqs = r.quotas
+		}
+		for _, kq := range kqs {
+			addQuotaRows(tbl, r, kq, len(kqs) > 1, multi, o)
+		}
+	}
+	if r.terminated != nil {
+		err = cli.AddRow(tbl, r.terminated.Sum(), withCluster(multi, r.cluster, labelPrefix(o.groupBy, "Terminated")...))
+		if err != nil {
+			klog.Fatalf("Could not add terminated row to table: %v", err)
+		}
+	}
+}
+
+// addQuotaRows adds the row for a quota, along with the workload's usage of it when that was asked for. Quotas with scopes are compared
+// against the pods that they match rather than against the namespace total.
+func addQuotaRows(tbl *cli.TableWriterHeaderTracker, r *workloadResult, kq *quota.KubeQuota, multiple, multi bool, o *workloadOptions) {
+	err := cli.AddRow(tbl, kq, withCluster(multi, r.cluster, labelPrefix(o.groupBy, quotaLabel("Quota", kq, multiple))...))
+	if err != nil {
+		klog.Fatalf("Could not add quota row to table: %v", err)
+	}
+	if !o.showUsage {
+		return
+	}
+
+	nwq := r.nq
+	if r.scoped != nil {
+		nwq = r.scoped.For(kq, r.nq)
+	}
+	var qu cli.HeaderValuer = &quota.QuotaUsage{
+		KQ:  kq,
+		NWQ: nwq,
+	}
+	if r.previous != nil && nwq == r.nq {
+		qu = quota.NewDelta(qu, r.previous, r.wq)
+	}
+	err = cli.AddRow(tbl, qu, withCluster(multi, r.cluster, labelPrefix(o.groupBy, quotaLabel("Usage", kq, multiple))...))
+	if err != nil {
+		klog.Fatalf("Could not add usage row to table: %v", err)
+	}
+	if kq.HasStatus() {
+		addStatusRow(tbl, r, kq, nwq.Sum(), multiple, multi, o)
+	}
+}
+
+// addStatusRow adds the usage that the API server recorded in the quota's status. Resources where the usage that was summed up from pods
+// and claims differs are marked with the difference, and the row is flagged as having drifted.
+func addStatusRow(tbl *cli.TableWriterHeaderTracker, r *workloadResult, kq *quota.KubeQuota, wq *quota.WorkloadQuota, multiple, multi bool,
+	o *workloadOptions) {
+	label := withDrift(quotaLabel("Status", kq, multiple), kq, wq)
+	status := quota.NewDelta(&quota.QuotaStatus{KQ: kq}, kq.UsedWorkload(), wq)
+	err := cli.AddRow(tbl, status, withCluster(multi, r.cluster, labelPrefix(o.groupBy, label)...))
+	if err != nil {
+		klog.Fatalf("Could not add status row to table: %v", err)
+	}
+}
+
+// withDrift appends the resources where the quota's status disagrees with the usage that was summed up to a label
+func withDrift(label string, kq *quota.KubeQuota, wq *quota.WorkloadQuota) string {
+	drift, err := kq.Drift(wq)
+	if err != nil {
+		klog.Fatalf("Could not compare usage against quota status: %v", err)
+	}
+	if len(drift) > 0 {
+		label = fmt.Sprintf("%s (drift: %s)", label, strings.Join(drift, ", "))
+	}
+	return label
+}
+
 func workloadValidateInput(cmd *cobra.Command) error {
-	err := cmd.MarkFlagRequired("namespace")
+	err := validateNamespaceFlags(cmd)
 	if err != nil {
 		return err
 	}
+	if getFlagBool(cmd, "all-namespaces") {
+		if getFlagInt(cmd, "concurrency") < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		for _, flag := range []string{"watch", "selector", "field-selector", "show-terminated"} {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s cannot be combined with --all-namespaces", flag)
+			}
+		}
+		if getFlagString(cmd, "group-by") != groupByNone {
+			return fmt.Errorf("--group-by cannot be combined with --all-namespaces")
+		}
+	}
+
+	switch groupBy := getFlagString(cmd, "group-by"); groupBy {
+	case groupByOwner, groupByPod, groupByContainer, groupByNone:
+	default:
+		return fmt.Errorf("--group-by must be one of %s, %s, %s or %s, got %q", groupByOwner, groupByPod, groupByContainer, groupByNone,
+			groupBy)
+	}
+	if getFlagBool(cmd, "watch") && getFlagString(cmd, "group-by") != groupByNone {
+		return fmt.Errorf("--group-by cannot be combined with --watch")
+	}
+	sel := kubernetes.PodSelector{
+		Labels: getFlagString(cmd, "selector"),
+		Fields: getFlagString(cmd, "field-selector"),
+	}
+	err = sel.Validate()
+	if err != nil {
+		return err
+	}
+	if getFlagBool(cmd, "watch") && !sel.IsEmpty() {
+		return fmt.Errorf("--selector and --field-selector cannot be combined with --watch")
+	}
+	if getFlagBool(cmd, "include-terminated") && getFlagBool(cmd, "show-terminated") {
+		return fmt.Errorf("--include-terminated and --show-terminated cannot be used together")
+	}
 
 	return nil
 }
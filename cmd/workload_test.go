@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestWorkloadCommand(t *testing.T) {
+	done := testPod("team", "done", "4", "4Gi", "4", "4Gi")
+	done.Status.Phase = v1.PodSucceeded
+	objs := []runtime.Object{
+		testPod("team", "web", "500m", "1Gi", "1", "2Gi"),
+		testPod("team", "worker", "1", "1Gi", "2", "2Gi"),
+		done,
+		testPod("other", "web", "8", "8Gi", "8", "8Gi"),
+		testQuota("team", "compute", "4", "8Gi", "8", "16Gi"),
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		rows map[string][]string
+	}{
+		{
+			name: "total",
+			args: []string{"workload", "-n", "team"},
+			rows: map[string][]string{
+				"Total": {"1.5 Cores", "2.0 GB", "3.0 Cores", "4.0 GB"},
+			},
+		},
+		{
+			name: "with quota",
+			args: []string{"workload", "-n", "team", "--add-quota"},
+			rows: map[string][]string{
+				"Total": {"1.5 Cores", "2.0 GB", "3.0 Cores", "4.0 GB"},
+				"Quota": {"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"},
+			},
+		},
+		{
+			name: "with usage",
+			args: []string{"workload", "-n", "team", "--show-usage"},
+			rows: map[string][]string{
+				"Usage":     {"1.5 Cores (37.50%)", "2.0 GB (25.00%)", "3.0 Cores (37.50%)", "4.0 GB (25.00%)"},
+				"Remaining": nil,
+			},
+		},
+		{
+			name: "including terminated pods",
+			args: []string{"workload", "-n", "team", "--include-terminated"},
+			rows: map[string][]string{
+				"Total": {"5.5 Cores", "6.0 GB", "7.0 Cores", "8.0 GB"},
+			},
+		},
+		{
+			name: "showing terminated pods",
+			args: []string{"workload", "-n", "team", "--show-terminated"},
+			rows: map[string][]string{
+				"Total":      {"1.5 Cores", "2.0 GB", "3.0 Cores", "4.0 GB"},
+				"Terminated": {"4.0 Cores", "4.0 GB", "4.0 Cores", "4.0 GB"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runKubeQuota(t, objs, tt.args...)
+			for label, want := range tt.rows {
+				if want == nil {
+					if tableRow(out, label) != nil {
+						t.Errorf("unexpected %s row in:\n%s", label, out)
+					}
+					continue
+				}
+				assertRow(t, out, []string{label}, want)
+			}
+		})
+	}
+}
+
+func TestWorkloadCommandGroupByPod(t *testing.T) {
+	objs := []runtime.Object{
+		testPod("team", "web", "500m", "1Gi", "1", "2Gi"),
+		testPod("team", "worker", "1", "1Gi", "2", "2Gi"),
+	}
+
+	out := runKubeQuota(t, objs, "workload", "-n", "team", "--group-by", "pod")
+	assertRow(t, out, []string{"team", "web"}, []string{"500 Millicores", "1.0 GB", "1.0 Cores", "2.0 GB"})
+	assertRow(t, out, []string{"team", "worker"}, []string{"1.0 Cores", "1.0 GB", "2.0 Cores", "2.0 GB"})
+	assertRow(t, out, []string{"Total", ""}, []string{"1.5 Cores", "2.0 GB", "3.0 Cores", "4.0 GB"})
+}
+
+func TestWorkloadCommandAllNamespaces(t *testing.T) {
+	objs := []runtime.Object{
+		testPod("team", "web", "1", "2Gi", "2", "4Gi"),
+		testPod("other", "web", "500m", "1Gi", "1", "1Gi"),
+		testPod("unlimited", "web", "8", "8Gi", "8", "8Gi"),
+		testQuota("team", "compute", "4", "8Gi", "8", "16Gi"),
+		testQuota("other", "compute", "1", "2Gi", "2", "4Gi"),
+	}
+
+	out := runKubeQuota(t, objs, "workload", "-A")
+	assertRow(t, out, []string{"other", "compute"}, []string{"500 Millicores (50.00%)", "1.0 GB (50.00%)", "1.0 Cores (50.00%)",
+		"1.0 GB (25.00%)"})
+	assertRow(t, out, []string{"team", "compute"}, []string{"1.0 Cores (25.00%)", "2.0 GB (25.00%)", "2.0 Cores (25.00%)",
+		"4.0 GB (25.00%)"})
+	// Namespaces without a quota aren't part of the total
+	assertRow(t, out, []string{"Total", ""}, []string{"1.5 Cores (30.00%)", "3.0 GB (30.00%)", "3.0 Cores (30.00%)",
+		"5.0 GB (25.00%)"})
+}
+
+func TestWorkloadCommandMultipleClusters(t *testing.T) {
+	objs := []runtime.Object{
+		testPod("team", "web", "1", "2Gi", "2", "4Gi"),
+		testQuota("team", "compute", "4", "8Gi", "8", "16Gi"),
+	}
+
+	out := runKubeQuota(t, objs, "workload", "-n", "team", "--add-quota", "--contexts", "east,west")
+	assertRow(t, out, []string{"east", "Total"}, []string{"1.0 Cores", "2.0 GB", "2.0 Cores", "4.0 GB"})
+	assertRow(t, out, []string{"west", "Quota"}, []string{"4.0 Cores", "8.0 GB", "8.0 Cores", "16.0 GB"})
+	assertRow(t, out, []string{"All Clusters", "Total"}, []string{"2.0 Cores", "4.0 GB", "4.0 Cores", "8.0 GB"})
+}
+
+func TestWorkloadCommandStatusDrift(t *testing.T) {
+	// Memory was only just added to the quota, so the quota controller doesn't track it in the status yet
+	statusQuota := func(usedCPU string) *v1.ResourceQuota {
+		rq := &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "team"},
+			Spec: v1.ResourceQuotaSpec{Hard: v1.ResourceList{
+				v1.ResourceRequestsCPU:    resource.MustParse("4"),
+				v1.ResourceRequestsMemory: resource.MustParse("8Gi"),
+			}},
+		}
+		rq.Status.Hard = v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse("4")}
+		rq.Status.Used = v1.ResourceList{v1.ResourceRequestsCPU: resource.MustParse(usedCPU)}
+		return rq
+	}
+
+	tests := []struct {
+		name    string
+		usedCPU string
+		label   string
+		want    []string
+	}{
+		{name: "matches the status", usedCPU: "1", label: "Status", want: []string{"1.0 Cores (25.00%)", "", "", ""}},
+		{name: "behind the pods", usedCPU: "500m", label: "Status (drift: CPU Request)",
+			want: []string{"500 Millicores (12.50%) [+500 Millicores]", "", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []runtime.Object{testPod("team", "web", "1", "2Gi", "2", "4Gi"), statusQuota(tt.usedCPU)}
+			out := runKubeQuota(t, objs, "workload", "-n", "team", "--show-usage")
+			assertRow(t, out, []string{tt.label}, tt.want)
+		})
+	}
+}
+
+// selectorPodsYAML is read from a file because the fake clientset doesn't apply field selectors
+const selectorPodsYAML = `apiVersion: v1
+kind: Pod
+metadata: {name: web-1, namespace: team, labels: {app: web}}
+spec:
+  nodeName: node1
+  containers:
+  - {name: app, image: app, resources: {requests: {cpu: "1"}}}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: web-2, namespace: team, labels: {app: web}}
+spec:
+  nodeName: node2
+  containers:
+  - {name: app, image: app, resources: {requests: {cpu: "2"}}}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: worker, namespace: team, labels: {app: worker}}
+spec:
+  nodeName: node1
+  containers:
+  - {name: app, image: app, resources: {requests: {cpu: "4"}}}
+`
+
+func TestWorkloadCommandSelectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		label string
+		want  string
+	}{
+		{name: "labels", args: []string{"-l", "app=web"}, label: "app=web", want: "3.0 Cores"},
+		{name: "fields", args: []string{"--field-selector", "spec.nodeName=node1"}, label: "spec.nodeName=node1", want: "5.0 Cores"},
+		{name: "both", args: []string{"-l", "app=web", "--field-selector", "spec.nodeName=node1"},
+			label: "app=web,spec.nodeName=node1", want: "1.0 Cores"},
+		{name: "no match", args: []string{"--field-selector", "spec.nodeName=node3"}, label: "spec.nodeName=node3", want: "0 Millicores"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"workload", "-n", "team", "-f", writeObjectsFile(t, selectorPodsYAML)}, tt.args...)
+			out := runKubeQuota(t, nil, args...)
+			assertRow(t, out, []string{tt.label}, []string{tt.want, "0 B", "0 Millicores", "0 B"})
+			// The total still holds every pod in the namespace
+			assertRow(t, out, []string{"Total"}, []string{"7.0 Cores", "0 B", "0 Millicores", "0 B"})
+		})
+	}
+}
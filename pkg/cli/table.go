@@ -2,7 +2,7 @@ package cli
 
 import (
 	"errors"
-	"os"
+	"io"
 
 	"github.com/aauren/kube-quota/pkg/quota"
 	"github.com/aauren/kube-quota/pkg/unit"
@@ -12,7 +12,7 @@ import (
 
 var (
 	allHeaderOrder = []string{quota.HeaderCPUReq, quota.HeaderMemReq, quota.HeaderCPULim, quota.HeaderMemLim,
-		quota.HeaderEphemeralStorageReq, quota.HeaderEphemeralStorageLim}
+		quota.HeaderEphemeralStorageReq, quota.HeaderEphemeralStorageLim, quota.HeaderStorageReq, quota.HeaderPVCs}
 )
 
 type TableHeaderer interface {
@@ -47,11 +47,11 @@ func (t *TableWriterHeaderTracker) OrderedHeaders() []string {
 	return t.orderedHeaders
 }
 
-func CreateTableWriter() *TableWriterHeaderTracker {
+func CreateTableWriter(out io.Writer) *TableWriterHeaderTracker {
 	tbl := table.Table{}
 
-	// Set output to be STDOUT
-	tbl.SetOutputMirror(os.Stdout)
+	// Set output to be the writer that we were given (most commonly STDOUT)
+	tbl.SetOutputMirror(out)
 
 	// Set basic overall formatting to StyleColoredBright
 	tbl.SetStyle(table.StyleColoredBright)
@@ -89,9 +89,18 @@ func configureColumns(tbl *TableWriterHeaderTracker) {
 
 func AddTableHeader(tbl *TableWriterHeaderTracker, headerPrefixs []string, headerGenerators ...TableHeaderer) {
 
-	// Create a list of unique headers that are used by all header generators that are passed
+	// Create a list of unique headers that are used by all header generators that are passed, headers that only exist at runtime (like
+	// the ones for each storage class) are kept in the order they were first seen in
+	knownHeaders := make(map[string]bool, len(allHeaderOrder))
+	for _, hdr := range allHeaderOrder {
+		knownHeaders[hdr] = true
+	}
+	dynamicHeaders := make([]string, 0)
 	for _, hdrGen := range headerGenerators {
 		for _, hdr := range hdrGen.TableHeader() {
+			if !tbl.uniqueHeaders[hdr] && !knownHeaders[hdr] {
+				dynamicHeaders = append(dynamicHeaders, hdr)
+			}
 			tbl.uniqueHeaders[hdr] = true
 		}
 	}
@@ -107,6 +116,7 @@ func AddTableHeader(tbl *TableWriterHeaderTracker, headerPrefixs []string, heade
 			tbl.orderedHeaders = append(tbl.orderedHeaders, hdr)
 		}
 	}
+	tbl.orderedHeaders = append(tbl.orderedHeaders, dynamicHeaders...)
 
 	headerRow := make(table.Row, len(tbl.orderedHeaders))
 	for i, hdr := range tbl.orderedHeaders {
@@ -120,7 +130,7 @@ func AddTableHeader(tbl *TableWriterHeaderTracker, headerPrefixs []string, heade
 }
 
 func AddRow(tbl OrderedTableWriter, hv HeaderValuer, prefixes []string) error {
-	values := make([]interface{}, len(tbl.OrderedHeaders())+len(prefixes)-1)
+	values := make([]interface{}, len(tbl.OrderedHeaders()))
 
 	if len(prefixes) > 0 {
 		for i, pref := range prefixes {
@@ -129,16 +139,16 @@ func AddRow(tbl OrderedTableWriter, hv HeaderValuer, prefixes []string) error {
 	}
 
 	for i, hdr := range tbl.OrderedHeaders()[len(prefixes):] {
-		var err error
-		notFound := quota.NoValueForHeaderError{Header: hdr}
-		values[i+len(prefixes)], err = hv.ValueForHeader(hdr)
+		val, err := hv.ValueForHeader(hdr)
 		if err != nil {
-			if errors.Is(err, &notFound) {
-				values[i] = ""
+			var notFound *quota.NoValueForHeaderError
+			if errors.As(err, &notFound) {
+				values[i+len(prefixes)] = ""
 				continue
 			}
 			return err
 		}
+		values[i+len(prefixes)] = val
 	}
 
 	tbl.AppendRow(values)
@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessCheck describes a single API permission that a command needs in order to do its work
+type AccessCheck struct {
+	Verb      string
+	Group     string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+func (a AccessCheck) String() string {
+	resource := a.Resource
+	if a.Group != "" {
+		resource = fmt.Sprintf("%s.%s", a.Resource, a.Group)
+	}
+	if a.Name != "" {
+		resource = fmt.Sprintf("%s/%s", resource, a.Name)
+	}
+	if a.Namespace == "" {
+		return fmt.Sprintf("%s on %s", a.Verb, resource)
+	}
+	return fmt.Sprintf("%s on %s in ns %s", a.Verb, resource, a.Namespace)
+}
+
+// MissingAccessError is returned by CheckAccess when the current user is not allowed to do everything that a command needs
+type MissingAccessError struct {
+	Command string
+	Missing []AccessCheck
+}
+
+func (m *MissingAccessError) Error() string {
+	needs := make([]string, 0, len(m.Missing))
+	for _, a := range m.Missing {
+		needs = append(needs, fmt.Sprintf("%s needs %s", m.Command, a))
+	}
+	return strings.Join(needs, "; ")
+}
+
+// CheckAccess asks the API server, via SelfSubjectAccessReviews, whether the current (or impersonated) user is allowed to perform every
+// one of the passed checks. If any of them are denied a *MissingAccessError naming each missing permission is returned. Offline clients
+// have no permissions to check and always pass.
+func (c *Client) CheckAccess(ctx context.Context, command string, checks ...AccessCheck) error {
+	if c.offline {
+		return nil
+	}
+
+	missing := make([]AccessCheck, 0)
+	for _, check := range checks {
+		ssar := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb:      check.Verb,
+					Group:     check.Group,
+					Resource:  check.Resource,
+					Namespace: check.Namespace,
+					Name:      check.Name,
+				},
+			},
+		}
+		resp, err := c.k8s.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("could not check whether %s is allowed: %w", check, err)
+		}
+		if !resp.Status.Allowed {
+			missing = append(missing, check)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingAccessError{Command: command, Missing: missing}
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package kubernetes
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Client) ListClaimsByNS(ctx context.Context, ns string) (*v1.PersistentVolumeClaimList, error) {
+	return c.k8s.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+}
+
+// ClaimAccessChecks returns the permissions that ListClaimsByNS needs
+func ClaimAccessChecks(ns string) []AccessCheck {
+	return []AccessCheck{{Verb: "list", Resource: "persistentvolumeclaims", Namespace: ns}}
+}
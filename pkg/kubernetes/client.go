@@ -1,20 +1,211 @@
 package kubernetes
 
 import (
-	"path/filepath"
+	"errors"
+	"sort"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
 )
 
-func GetClientSet() (*kubernetes.Clientset, error) {
-	cfg, err := clientcmd.BuildConfigFromFlags("", filepath.Join(homedir.HomeDir(), ".kube", "config"))
+// ConfigOptions holds the user supplied options that control how the Kubernetes client configuration is loaded. Any option left empty
+// falls back to the standard clientcmd loading rules ($KUBECONFIG, then ~/.kube/config) and the current context of the merged config.
+//
+// When no kubeconfig can be found at all, the clientcmd loader automatically falls back to the in-cluster service account
+// configuration, so running inside of a Pod works without any options. InCluster forces the in-cluster configuration even when a
+// kubeconfig happens to exist.
+type ConfigOptions struct {
+	Kubeconfig string
+	Context    string
+	Cluster    string
+	User       string
+	InCluster  bool
+
+	// Impersonate and ImpersonateGroups are applied on top of whichever configuration was loaded, the same as kubectl's --as and
+	// --as-group flags
+	Impersonate       string
+	ImpersonateGroups []string
+
+	// Timeout, QPS and Burst tune the client, they are left at client-go's defaults when zero
+	Timeout time.Duration
+	QPS     float32
+	Burst   int
+}
+
+func (o *ConfigOptions) hasKubeconfigOverrides() bool {
+	return o.Kubeconfig != "" || o.Context != "" || o.Cluster != "" || o.User != ""
+}
+
+// ClientConfig returns a clientcmd.ClientConfig built from the standard loading rules with the user's overrides applied on top
+func (o *ConfigOptions) ClientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.Kubeconfig
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: o.Context,
+	}
+	overrides.Context.Cluster = o.Cluster
+	overrides.Context.AuthInfo = o.User
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+func GetRESTConfig(opts *ConfigOptions) (*rest.Config, error) {
+	var cfg *rest.Config
+	var err error
+	if opts.InCluster {
+		if opts.hasKubeconfigOverrides() {
+			return nil, errors.New("in-cluster configuration cannot be combined with kubeconfig, context, cluster, or user options")
+		}
+		cfg, err = rest.InClusterConfig()
+	} else {
+		cfg, err = opts.ClientConfig().ClientConfig()
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.Timeout > 0 {
+		cfg.Timeout = opts.Timeout
+	}
+	if opts.QPS > 0 {
+		cfg.QPS = opts.QPS
+	}
+	if opts.Burst > 0 {
+		cfg.Burst = opts.Burst
+	}
+
+	if opts.Impersonate != "" || len(opts.ImpersonateGroups) > 0 {
+		if opts.Impersonate == "" {
+			return nil, errors.New("impersonating groups requires a user to impersonate as well")
+		}
+		cfg.Impersonate = rest.ImpersonationConfig{
+			UserName: opts.Impersonate,
+			Groups:   opts.ImpersonateGroups,
+		}
+	}
+
+	return cfg, nil
+}
+
+// Client carries all of the lookups that kube-quota performs against the API server. It is built once per command so that every
+// lookup shares the same clientset, and it accepts any kubernetes.Interface so that a fake clientset can be used in its place.
+type Client struct {
+	k8s kubernetes.Interface
+	// dyn, md and mapper are used to list and count objects of any resource, including custom resources, they are nil for clients that
+	// were built with NewClientForInterface
+	dyn    dynamic.Interface
+	md     metadata.Interface
+	mapper meta.RESTMapper
+	// offline is set when the client serves objects read from files rather than talking to an API server
+	offline bool
+}
+
+func NewClient(opts *ConfigOptions) (*Client, error) {
+	cfg, err := GetRESTConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dynamic client can only speak JSON, so it is built before the config is switched over to protobuf
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	md, err := metadata.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Core resources like pods are much cheaper to transfer and decode as protobuf, which matters for very large namespaces
+	cfg = rest.CopyConfig(cfg)
+	cfg.ContentType = runtime.ContentTypeProtobuf
+	cfg.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+
 	k8s, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClientForInterface(k8s)
+	c.dyn = dyn
+	c.md = md
+	// Discovery is only done the first time that a resource needs to be looked up, and only once per command
+	c.mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(k8s.Discovery()))
+	return c, nil
+}
+
+// GetClientSet builds a clientset from the default kubeconfig and its current context.
+//
+// Deprecated: build a Client with NewClient and use its methods, or its Interface for lookups that it doesn't carry.
+func GetClientSet() (*kubernetes.Clientset, error) {
+	c, err := NewClient(&ConfigOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// NewClient always builds a *kubernetes.Clientset
+	return c.k8s.(*kubernetes.Clientset), nil
+}
+
+func NewClientForInterface(k8s kubernetes.Interface) *Client {
+	return &Client{k8s: k8s}
+}
+
+func (c *Client) Interface() kubernetes.Interface {
+	return c.k8s
+}
+
+// Offline returns true when the client serves objects read from files rather than talking to an API server
+func (c *Client) Offline() bool {
+	return c.offline
+}
+
+// ContextNames returns the names of every context in the merged kubeconfig, sorted alphabetically
+func ContextNames(opts *ConfigOptions) ([]string, error) {
+	raw, err := opts.ClientConfig().RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// ClusterName returns the name of the kubeconfig cluster that the options resolve to, or an empty string if it cannot be determined
+// (for instance when running in-cluster)
+func ClusterName(opts *ConfigOptions) string {
+	if opts.InCluster {
+		return ""
+	}
+	if opts.Cluster != "" {
+		return opts.Cluster
+	}
+
+	raw, err := opts.ClientConfig().RawConfig()
+	if err != nil {
+		return ""
+	}
+	kubeContext := opts.Context
+	if kubeContext == "" {
+		kubeContext = raw.CurrentContext
+	}
+	if c, ok := raw.Contexts[kubeContext]; ok {
+		return c.Cluster
+	}
 
-	return k8s, err
+	return ""
 }
@@ -0,0 +1,178 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// clusterQuotaGroup is the API group that OpenShift serves its quotas that span namespaces from
+const clusterQuotaGroup = "quota.openshift.io"
+
+var (
+	clusterResourceQuotaGVK        = schema.GroupVersionKind{Group: clusterQuotaGroup, Version: "v1", Kind: "ClusterResourceQuota"}
+	appliedClusterResourceQuotaGVK = schema.GroupVersionKind{Group: clusterQuotaGroup, Version: "v1", Kind: "AppliedClusterResourceQuota"}
+
+	clusterResourceQuotaResource        = schema.GroupResource{Group: clusterQuotaGroup, Resource: "clusterresourcequotas"}
+	appliedClusterResourceQuotaResource = schema.GroupResource{Group: clusterQuotaGroup, Resource: "appliedclusterresourcequotas"}
+)
+
+// ClusterResourceQuota holds the parts of an OpenShift ClusterResourceQuota (or an AppliedClusterResourceQuota, which is the same quota
+// as seen from one of the namespaces that it selects) that kube-quota reads. They are read through the dynamic client so that kube-quota
+// doesn't depend on OpenShift's API types.
+type ClusterResourceQuota struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ClusterResourceQuotaSpec   `json:"spec"`
+	Status            ClusterResourceQuotaStatus `json:"status,omitempty"`
+}
+
+type ClusterResourceQuotaSpec struct {
+	Selector ClusterResourceQuotaSelector `json:"selector"`
+	Quota    v1.ResourceQuotaSpec         `json:"quota"`
+}
+
+// ClusterResourceQuotaSelector selects the namespaces that a ClusterResourceQuota applies to, a namespace has to match both the label
+// selector and every annotation. Either of them may be left out.
+type ClusterResourceQuotaSelector struct {
+	Labels      *metav1.LabelSelector `json:"labels,omitempty"`
+	Annotations map[string]string     `json:"annotations,omitempty"`
+}
+
+// ClusterResourceQuotaStatus holds the usage that the quota controller recorded, Total is across every namespace that the quota selects
+// and Namespaces breaks it down by namespace
+type ClusterResourceQuotaStatus struct {
+	Total      v1.ResourceQuotaStatus           `json:"total,omitempty"`
+	Namespaces []ResourceQuotaStatusByNamespace `json:"namespaces,omitempty"`
+}
+
+// ResourceQuotaStatusByNamespace holds the usage of a ClusterResourceQuota within a single namespace that it selects
+type ResourceQuotaStatusByNamespace struct {
+	Namespace string                 `json:"namespace"`
+	Status    v1.ResourceQuotaStatus `json:"status"`
+}
+
+// Selects returns true if the quota applies to the namespace
+func (q *ClusterResourceQuota) Selects(ns *v1.Namespace) (bool, error) {
+	if q.Spec.Selector.Labels != nil {
+		selector, err := metav1.LabelSelectorAsSelector(q.Spec.Selector.Labels)
+		if err != nil {
+			return false, fmt.Errorf("invalid label selector in cluster resource quota %s: %w", q.Name, err)
+		}
+		if !selector.Matches(labels.Set(ns.Labels)) {
+			return false, nil
+		}
+	}
+	for key, val := range q.Spec.Selector.Annotations {
+		if cur, ok := ns.Annotations[key]; !ok || cur != val {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ResourceQuota returns the quota as a ResourceQuota so that it can be used anywhere that a ResourceQuota can, its status holds the usage
+// across every namespace that it selects
+func (q *ClusterResourceQuota) ResourceQuota() *v1.ResourceQuota {
+	return &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: q.Name},
+		Spec:       q.Spec.Quota,
+		Status:     q.Status.Total,
+	}
+}
+
+// NamespaceResourceQuota returns the quota as a ResourceQuota like ResourceQuota does, but with the usage that its status recorded for a
+// single namespace. ok is false when the status doesn't include the namespace.
+func (q *ClusterResourceQuota) NamespaceResourceQuota(ns string) (rq *v1.ResourceQuota, ok bool) {
+	for _, nsStatus := range q.Status.Namespaces {
+		if nsStatus.Namespace == ns {
+			rq = q.ResourceQuota()
+			rq.Status = nsStatus.Status
+			return rq, true
+		}
+	}
+	return nil, false
+}
+
+// ToUnstructured returns the quota as a ClusterResourceQuota object, which is how it is stored in files even when it was read as an
+// AppliedClusterResourceQuota
+func (q *ClusterResourceQuota) ToUnstructured() (*unstructured.Unstructured, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(q)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetGroupVersionKind(clusterResourceQuotaGVK)
+	return u, nil
+}
+
+// ListClusterResourceQuotas returns every ClusterResourceQuota sorted by name. When a namespace is given, only the quotas that apply to
+// it are returned, which are read through AppliedClusterResourceQuotas so that users without cluster wide permissions can see them as
+// well. The API is found through discovery, clusters that don't serve it (anything other than OpenShift) return an error that
+// meta.IsNoMatchError recognizes.
+func (c *Client) ListClusterResourceQuotas(ctx context.Context, ns string) ([]*ClusterResourceQuota, error) {
+	gr, listNS := clusterResourceQuotaResource, ns
+	// AppliedClusterResourceQuotas are served by the API server from the ClusterResourceQuotas, so files only ever hold the latter. The
+	// ones that apply to the namespace are the ones whose status includes it.
+	if ns != "" && !c.offline {
+		gr = appliedClusterResourceQuotaResource
+	} else {
+		listNS = ""
+	}
+
+	crqs := make([]*ClusterResourceQuota, 0)
+	err := c.ForEachObject(ctx, listNS, gr, func(u *unstructured.Unstructured) error {
+		var crq ClusterResourceQuota
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &crq)
+		if err != nil {
+			return fmt.Errorf("could not decode %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		if _, ok := crq.NamespaceResourceQuota(ns); ns != "" && c.offline && !ok {
+			return nil
+		}
+		crqs = append(crqs, &crq)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(crqs, func(i, j int) bool {
+		return crqs[i].Name < crqs[j].Name
+	})
+	return crqs, nil
+}
+
+// ListNamespaces returns every namespace in the cluster
+func (c *Client) ListNamespaces(ctx context.Context) (*v1.NamespaceList, error) {
+	return c.k8s.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+}
+
+// ClusterResourceQuotaAccessChecks returns the permissions that ListClusterResourceQuotas needs. Without a namespace, the namespaces have
+// to be listed as well to find the ones that each quota selects.
+func ClusterResourceQuotaAccessChecks(ns string) []AccessCheck {
+	if ns == "" {
+		return []AccessCheck{
+			{Verb: "list", Resource: "namespaces"},
+			{Verb: "list", Group: clusterQuotaGroup, Resource: clusterResourceQuotaResource.Resource},
+		}
+	}
+	return []AccessCheck{{Verb: "list", Group: clusterQuotaGroup, Resource: appliedClusterResourceQuotaResource.Resource, Namespace: ns}}
+}
+
+// GetNamespace returns a single namespace
+func (c *Client) GetNamespace(ctx context.Context, ns string) (*v1.Namespace, error) {
+	return c.k8s.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
+}
+
+// NamespaceAccessChecks returns the permissions that GetNamespace needs. The API server treats getting a namespace as a request within
+// that namespace, so a Role in it is enough to grant this.
+func NamespaceAccessChecks(ns string) []AccessCheck {
+	return []AccessCheck{{Verb: "get", Resource: "namespaces", Namespace: ns, Name: ns}}
+}
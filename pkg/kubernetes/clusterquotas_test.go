@@ -0,0 +1,159 @@
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const clusterQuotaYAML = `apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata:
+  name: team-a
+spec:
+  selector:
+    labels: {matchLabels: {team: a}}
+  quota:
+    hard: {requests.cpu: "4"}
+status:
+  total:
+    hard: {requests.cpu: "4"}
+    used: {requests.cpu: "3"}
+  namespaces:
+  - namespace: dev
+    status:
+      hard: {requests.cpu: "4"}
+      used: {requests.cpu: "1"}
+  - namespace: prod
+    status:
+      hard: {requests.cpu: "4"}
+      used: {requests.cpu: "2"}
+---
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata:
+  name: team-b
+spec:
+  selector:
+    labels: {matchLabels: {team: b}}
+  quota:
+    hard: {requests.cpu: "2"}
+status:
+  namespaces:
+  - namespace: other
+    status:
+      used: {requests.cpu: "1"}
+`
+
+func readClusterQuotas(t *testing.T, ns string) []*ClusterResourceQuota {
+	t.Helper()
+	objs, err := ReadObjects(strings.NewReader(clusterQuotaYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClientForObjects(objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crqs, err := c.ListClusterResourceQuotas(context.Background(), ns)
+	if err != nil {
+		t.Fatalf("ListClusterResourceQuotas(%q) error = %v", ns, err)
+	}
+	return crqs
+}
+
+func TestListClusterResourceQuotasOffline(t *testing.T) {
+	tests := []struct {
+		ns   string
+		want []string
+	}{
+		{ns: "", want: []string{"team-a", "team-b"}},
+		{ns: "dev", want: []string{"team-a"}},
+		{ns: "other", want: []string{"team-b"}},
+		{ns: "unselected", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ns, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, crq := range readClusterQuotas(t, tt.ns) {
+				got = append(got, crq.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListClusterResourceQuotas(%q) = %v, want %v", tt.ns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceResourceQuota(t *testing.T) {
+	crq := readClusterQuotas(t, "")[0]
+	if got := len(crq.Status.Namespaces); got != 2 {
+		t.Fatalf("status has %d namespaces, want 2", got)
+	}
+
+	tests := []struct {
+		ns     string
+		wantOK bool
+		want   string
+	}{
+		{ns: "dev", wantOK: true, want: "1"},
+		{ns: "prod", wantOK: true, want: "2"},
+		{ns: "other", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ns, func(t *testing.T) {
+			rq, ok := crq.NamespaceResourceQuota(tt.ns)
+			if ok != tt.wantOK {
+				t.Fatalf("NamespaceResourceQuota(%q) ok = %t, want %t", tt.ns, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := rq.Spec.Hard[v1.ResourceRequestsCPU]; got.Cmp(resource.MustParse("4")) != 0 {
+				t.Errorf("hard requests.cpu = %s, want 4", got.String())
+			}
+			if got := rq.Status.Used[v1.ResourceRequestsCPU]; got.Cmp(resource.MustParse(tt.want)) != 0 {
+				t.Errorf("used requests.cpu = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+
+	// The total is left alone
+	if got := crq.ResourceQuota().Status.Used[v1.ResourceRequestsCPU]; got.Cmp(resource.MustParse("3")) != 0 {
+		t.Errorf("total used requests.cpu = %s, want 3", got.String())
+	}
+}
+
+func TestClusterResourceQuotaToUnstructured(t *testing.T) {
+	crq := readClusterQuotas(t, "")[0]
+	u, err := crq.ToUnstructured()
+	if err != nil {
+		t.Fatalf("ToUnstructured() error = %v", err)
+	}
+	if got := u.GroupVersionKind(); got != clusterResourceQuotaGVK {
+		t.Errorf("ToUnstructured() kind = %v, want %v", got, clusterResourceQuotaGVK)
+	}
+
+	// Stored quotas have to be read back the same way as any other file
+	c, err := NewClientForObjects(u, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crqs, err := c.ListClusterResourceQuotas(context.Background(), "prod")
+	if err != nil || len(crqs) != 1 {
+		t.Fatalf("ListClusterResourceQuotas() = %v, %v, want the stored quota", crqs, err)
+	}
+	if got := crqs[0]; got.Name != crq.Name || len(got.Status.Namespaces) != 2 || got.Spec.Selector.Labels.MatchLabels["team"] != "a" {
+		t.Errorf("stored quota = %+v, want %+v", got, crq)
+	}
+
+	n, err := c.GetNamespace(context.Background(), "dev")
+	if err != nil || n.Name != "dev" {
+		t.Errorf("GetNamespace() = %v, %v, want the dev namespace", n, err)
+	}
+}
@@ -0,0 +1,17 @@
+package kubernetes
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Client) ListLimitRangesByNS(ctx context.Context, ns string) (*v1.LimitRangeList, error) {
+	return c.k8s.CoreV1().LimitRanges(ns).List(ctx, metav1.ListOptions{})
+}
+
+// LimitRangeAccessChecks returns the permissions that ListLimitRangesByNS needs
+func LimitRangeAccessChecks(ns string) []AccessCheck {
+	return []AccessCheck{{Verb: "list", Resource: "limitranges", Namespace: ns}}
+}
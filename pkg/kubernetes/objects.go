@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// objectPageSize is the number of objects that are requested from the API server at a time when counting them
+const objectPageSize = 500
+
+func (c *Client) ListServicesByNS(ctx context.Context, ns string) (*v1.ServiceList, error) {
+	return c.k8s.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+}
+
+// ServiceAccessChecks returns the permissions that ListServicesByNS needs
+func ServiceAccessChecks(ns string) []AccessCheck {
+	return []AccessCheck{{Verb: "list", Resource: "services", Namespace: ns}}
+}
+
+// ForEachObject pages through the objects of any resource in a namespace through the dynamic client and calls fn for every one of them.
+// The resource is looked up through discovery, so custom resources work as well. When the API server doesn't serve the resource an
+// error that meta.IsNoMatchError recognizes is returned. Clients built with NewClientForInterface can't look up arbitrary resources, so
+// they return the same error for every resource.
+func (c *Client) ForEachObject(ctx context.Context, ns string, gr schema.GroupResource, fn func(*unstructured.Unstructured) error) error {
+	if c.dyn == nil || c.mapper == nil {
+		return &meta.NoResourceMatchError{PartialResource: gr.WithVersion("")}
+	}
+	gvr, err := c.mapper.ResourceFor(gr.WithVersion(""))
+	if err != nil {
+		return err
+	}
+
+	opts := metav1.ListOptions{Limit: objectPageSize}
+	for {
+		ul, err := c.dyn.Resource(gvr).Namespace(ns).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for idx := range ul.Items {
+			err = fn(&ul.Items[idx])
+			if err != nil {
+				return err
+			}
+		}
+
+		if ul.GetContinue() == "" {
+			return nil
+		}
+		opts.Continue = ul.GetContinue()
+	}
+}
+
+// ForEachObjectMetadata works just like ForEachObject, but only the metadata of each object is sent by the API server, which is all that
+// is needed to count or identify objects. The type of each object is set to the kind of the resource rather than
+// PartialObjectMetadata.
+func (c *Client) ForEachObjectMetadata(ctx context.Context, ns string, gr schema.GroupResource,
+	fn func(*metav1.PartialObjectMetadata) error) error {
+	if c.md == nil || c.mapper == nil {
+		return &meta.NoResourceMatchError{PartialResource: gr.WithVersion("")}
+	}
+	gvr, err := c.mapper.ResourceFor(gr.WithVersion(""))
+	if err != nil {
+		return err
+	}
+	gvk, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return err
+	}
+
+	opts := metav1.ListOptions{Limit: objectPageSize}
+	for {
+		pl, err := c.md.Resource(gvr).Namespace(ns).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for idx := range pl.Items {
+			pl.Items[idx].SetGroupVersionKind(gvk)
+			err = fn(&pl.Items[idx])
+			if err != nil {
+				return err
+			}
+		}
+
+		if pl.Continue == "" {
+			return nil
+		}
+		opts.Continue = pl.Continue
+	}
+}
+
+// CountObjects returns the number of objects of any resource in a namespace, see ForEachObjectMetadata
+func (c *Client) CountObjects(ctx context.Context, ns string, gr schema.GroupResource) (int64, error) {
+	var count int64
+	err := c.ForEachObjectMetadata(ctx, ns, gr, func(*metav1.PartialObjectMetadata) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// ObjectAccessChecks returns the permissions that CountObjects needs for each of the resources
+func ObjectAccessChecks(ns string, grs ...schema.GroupResource) []AccessCheck {
+	checks := make([]AccessCheck, 0, len(grs))
+	for _, gr := range grs {
+		checks = append(checks, AccessCheck{Verb: "list", Group: gr.Group, Resource: gr.Resource, Namespace: ns})
+	}
+	return checks
+}
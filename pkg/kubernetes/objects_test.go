@@ -0,0 +1,23 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCountObjectsWithoutDynamicClient(t *testing.T) {
+	// Clients built around a plain clientset can't look up arbitrary resources, which callers treat as the resource not being served
+	c := NewClientForInterface(fake.NewSimpleClientset())
+	_, err := c.CountObjects(context.Background(), "ns", schema.GroupResource{Resource: "configmaps"})
+	if !meta.IsNoMatchError(err) {
+		t.Errorf("CountObjects() error = %v, want no match", err)
+	}
+	_, err = c.ListClusterResourceQuotas(context.Background(), "ns")
+	if !meta.IsNoMatchError(err) {
+		t.Errorf("ListClusterResourceQuotas() error = %v, want no match", err)
+	}
+}
@@ -0,0 +1,246 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+)
+
+// offlineHost is the host that offline clients send their requests to, they never leave the process
+const offlineHost = "http://offline.kube-quota.invalid"
+
+// objectServer answers the API requests of offline clients from a fixed set of objects. Only reads are supported: lists (honoring label
+// and field selectors, but always in a single page), gets, and watches, which never see any changes. Lists are answered with only the
+// metadata of each object when that is all the client asks for.
+type objectServer struct {
+	mapper meta.RESTMapper
+	// objects holds every object keyed by its resource, in the order that they were read
+	objects map[schema.GroupResource][]*unstructured.Unstructured
+}
+
+func newObjectServer(mapper meta.RESTMapper, objs []runtime.Object) (*objectServer, error) {
+	srv := objectServer{mapper: mapper, objects: make(map[schema.GroupResource][]*unstructured.Unstructured)}
+	for _, obj := range objs {
+		gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, err
+		}
+		gvk := gvks[0]
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			// Versions other than the preferred one aren't mapped, but the object is still served for its resource
+			mapping, err = mapper.RESTMapping(gvk.GroupKind())
+		}
+		if err != nil {
+			klog.V(2).Infof("Skipping object of unmapped kind %s: %v", gvk, err)
+			continue
+		}
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert %s: %w", gvk.Kind, err)
+		}
+		u := &unstructured.Unstructured{Object: content}
+		u.SetGroupVersionKind(gvk)
+		gr := mapping.Resource.GroupResource()
+		srv.objects[gr] = append(srv.objects[gr], u)
+	}
+	return &srv, nil
+}
+
+// objectRequest is an API request broken down into what it asks for
+type objectRequest struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+// parseObjectRequest breaks down the path of an API request, such as /api/v1/namespaces/default/pods or /apis/apps/v1/replicasets
+func parseObjectRequest(path string) (*objectRequest, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var r objectRequest
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		r.gvr.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		r.gvr.Group, r.gvr.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		return nil, false
+	}
+
+	// A namespace followed by a resource is a namespaced request, otherwise namespaces are just another resource
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		r.namespace, parts = parts[1], parts[2:]
+	}
+	switch len(parts) {
+	case 1:
+		r.gvr.Resource = parts[0]
+	case 2:
+		r.gvr.Resource, r.name = parts[0], parts[1]
+	default:
+		// Subresources aren't served
+		return nil, false
+	}
+	return &r, true
+}
+
+func (s *objectServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Files don't record the version of the cluster that they were read from
+	if req.URL.Path == "/version" {
+		return objectResponse(req, &version.Info{})
+	}
+	r, ok := parseObjectRequest(req.URL.Path)
+	if !ok {
+		return statusResponse(req, apierrors.NewNotFound(schema.GroupResource{}, req.URL.Path))
+	}
+	if req.Method != http.MethodGet {
+		return statusResponse(req, apierrors.NewMethodNotSupported(r.gvr.GroupResource(), req.Method))
+	}
+	gvk, err := s.mapper.KindFor(r.gvr.GroupResource().WithVersion(""))
+	if err != nil {
+		return statusResponse(req, apierrors.NewNotFound(r.gvr.GroupResource(), r.name))
+	}
+
+	if r.name != "" {
+		for _, obj := range s.objects[r.gvr.GroupResource()] {
+			if obj.GetNamespace() == r.namespace && obj.GetName() == r.name {
+				return objectResponse(req, obj)
+			}
+		}
+		return statusResponse(req, apierrors.NewNotFound(r.gvr.GroupResource(), r.name))
+	}
+
+	query := req.URL.Query()
+	labelSelector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		return statusResponse(req, apierrors.NewBadRequest(err.Error()))
+	}
+	fieldSelector, err := fields.ParseSelector(query.Get("fieldSelector"))
+	if err != nil {
+		return statusResponse(req, apierrors.NewBadRequest(err.Error()))
+	}
+
+	if query.Get("watch") == "true" || query.Get("watch") == "1" {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+			Body:       &idleWatch{done: make(chan struct{}), cancelled: req.Context().Done()},
+			Request:    req,
+		}, nil
+	}
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion(r.gvr.GroupVersion().String())
+	list.SetKind(gvk.Kind + "List")
+	list.SetResourceVersion("1")
+	for _, obj := range s.objects[r.gvr.GroupResource()] {
+		if r.namespace != "" && obj.GetNamespace() != r.namespace {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(obj.GetLabels())) || !fieldSelector.Matches(objectFields(obj, fieldSelector)) {
+			continue
+		}
+		list.Items = append(list.Items, *obj)
+	}
+	if strings.Contains(req.Header.Get("Accept"), "as=PartialObjectMetadataList") {
+		return partialMetadataResponse(req, list)
+	}
+	return objectResponse(req, list)
+}
+
+// partialMetadataResponse answers a list request of the metadata client, which only asks for the metadata of each object
+func partialMetadataResponse(req *http.Request, list *unstructured.UnstructuredList) (*http.Response, error) {
+	typeMeta := metav1.TypeMeta{APIVersion: metav1.SchemeGroupVersion.String()}
+	pl := metav1.PartialObjectMetadataList{TypeMeta: typeMeta}
+	pl.TypeMeta.Kind = "PartialObjectMetadataList"
+	pl.ResourceVersion = list.GetResourceVersion()
+	for _, obj := range list.Items {
+		item := metav1.PartialObjectMetadata{TypeMeta: typeMeta}
+		item.TypeMeta.Kind = "PartialObjectMetadata"
+		metadata, _, err := unstructured.NestedMap(obj.Object, "metadata")
+		if err != nil {
+			return nil, err
+		}
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(metadata, &item.ObjectMeta)
+		if err != nil {
+			return nil, err
+		}
+		pl.Items = append(pl.Items, item)
+	}
+	return objectResponse(req, &pl)
+}
+
+// objectFields returns the value of every field that the selector looks at, fields that the object doesn't have are empty just like
+// they are to the API server
+func objectFields(obj *unstructured.Unstructured, selector fields.Selector) fields.Set {
+	set := fields.Set{}
+	for _, req := range selector.Requirements() {
+		val, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(req.Field, ".")...)
+		if found && err == nil && val != nil {
+			set[req.Field] = fmt.Sprint(val)
+		} else {
+			set[req.Field] = ""
+		}
+	}
+	return set
+}
+
+func objectResponse(req *http.Request, obj interface{}) (*http.Response, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{runtime.ContentTypeJSON}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    req,
+	}, nil
+}
+
+func statusResponse(req *http.Request, err *apierrors.StatusError) (*http.Response, error) {
+	status := err.ErrStatus
+	status.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}
+	resp, mErr := objectResponse(req, &status)
+	if mErr != nil {
+		return nil, mErr
+	}
+	resp.StatusCode = int(status.Code)
+	return resp, nil
+}
+
+// idleWatch is the body of a watch that never sees any events, it only ends once it is closed or its request is cancelled
+type idleWatch struct {
+	done      chan struct{}
+	once      sync.Once
+	cancelled <-chan struct{}
+}
+
+func (w *idleWatch) Read([]byte) (int, error) {
+	select {
+	case <-w.done:
+	case <-w.cancelled:
+	}
+	return 0, io.EOF
+}
+
+func (w *idleWatch) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
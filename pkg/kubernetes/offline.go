@@ -0,0 +1,276 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// StdinFilename is the filename that causes objects to be read from stdin rather than from a file
+	StdinFilename = "-"
+
+	yamlOrJSONBufferSize = 4096
+)
+
+// NewClientForObjects builds a client that serves the given objects instead of talking to an API server. This allows all of the normal
+// lookups to run against a copy of a cluster's objects, such as the output of kubectl get -o json. The clientset, dynamic client
+// and metadata client are the real ones, only their requests are answered by an objectServer rather than being sent over the network.
+func NewClientForObjects(objs ...runtime.Object) (*Client, error) {
+	mapper := offlineRESTMapper(objs...)
+	srv, err := newObjectServer(mapper, objs)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &rest.Config{
+		Host:      offlineHost,
+		Transport: srv,
+		// There is nothing to protect from a burst of requests, so don't rate limit at all
+		QPS: -1,
+	}
+	k8s, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	md, err := metadata.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClientForInterface(k8s)
+	c.dyn = dyn
+	c.md = md
+	c.mapper = mapper
+	c.offline = true
+	return c, nil
+}
+
+// offlineUnstructuredKinds holds the kinds outside of client-go's scheme that can be read from files, along with their scope
+var offlineUnstructuredKinds = map[schema.GroupVersionKind]meta.RESTScope{
+	clusterResourceQuotaGVK:        meta.RESTScopeRoot,
+	appliedClusterResourceQuotaGVK: meta.RESTScopeNamespace,
+}
+
+// clusterScopedKinds holds every kind in client-go's scheme that isn't namespaced, the scheme itself doesn't record this
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Kind: "Namespace"}:        true,
+	{Kind: "Node"}:             true,
+	{Kind: "PersistentVolume"}: true,
+	{Kind: "ComponentStatus"}:  true,
+
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:     true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: true,
+	{Group: "apiserverinternal.k8s.io", Kind: "StorageVersion"}:                       true,
+	{Group: "authentication.k8s.io", Kind: "TokenReview"}:                             true,
+	{Group: "authentication.k8s.io", Kind: "SelfSubjectReview"}:                       true,
+	{Group: "authorization.k8s.io", Kind: "SubjectAccessReview"}:                      true,
+	{Group: "authorization.k8s.io", Kind: "SelfSubjectAccessReview"}:                  true,
+	{Group: "authorization.k8s.io", Kind: "SelfSubjectRulesReview"}:                   true,
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}:                 true,
+	{Group: "certificates.k8s.io", Kind: "ClusterTrustBundle"}:                        true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}:                       true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}:       true,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:                                true,
+	{Group: "networking.k8s.io", Kind: "IPAddress"}:                                   true,
+	{Group: "networking.k8s.io", Kind: "ServiceCIDR"}:                                 true,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:                                      true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                         true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                  true,
+	{Group: "resource.k8s.io", Kind: "DeviceClass"}:                                   true,
+	{Group: "resource.k8s.io", Kind: "ResourceSlice"}:                                 true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                               true,
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                      true,
+	{Group: "storage.k8s.io", Kind: "CSINode"}:                                        true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                   true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:                               true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttributesClass"}:                          true,
+	{Group: "storagemigration.k8s.io", Kind: "StorageVersionMigration"}:               true,
+}
+
+// offlineRESTMapper maps the resources of every kind that kube-quota can read from files, along with the kinds of any of the objects
+// that it doesn't know about, such as custom resources. Only the preferred version of each group is mapped so that looking up a
+// resource without a version is never ambiguous.
+func offlineRESTMapper(objs ...runtime.Object) meta.RESTMapper {
+	unknown := unknownKinds(objs)
+	gvs := scheme.Scheme.PrioritizedVersionsAllGroups()
+	for gvk := range offlineUnstructuredKinds {
+		if !slices.Contains(gvs, gvk.GroupVersion()) {
+			gvs = append(gvs, gvk.GroupVersion())
+		}
+	}
+	for gvk := range unknown {
+		if !slices.Contains(gvs, gvk.GroupVersion()) {
+			gvs = append(gvs, gvk.GroupVersion())
+		}
+	}
+	mapper := meta.NewDefaultRESTMapper(gvs)
+	seen := make(map[string]bool)
+	for _, gv := range scheme.Scheme.PrioritizedVersionsAllGroups() {
+		if seen[gv.Group] {
+			continue
+		}
+		seen[gv.Group] = true
+		for kind := range scheme.Scheme.KnownTypes(gv) {
+			if strings.HasSuffix(kind, "List") {
+				continue
+			}
+			scope := meta.RESTScopeNamespace
+			if clusterScopedKinds[schema.GroupKind{Group: gv.Group, Kind: kind}] {
+				scope = meta.RESTScopeRoot
+			}
+			mapper.Add(gv.WithKind(kind), scope)
+		}
+	}
+	for gvk, scope := range offlineUnstructuredKinds {
+		mapper.Add(gvk, scope)
+	}
+	for gvk, scope := range unknown {
+		mapper.Add(gvk, scope)
+	}
+	return mapper
+}
+
+// unknownKinds returns the kinds of the objects that are neither in client-go's scheme nor in offlineUnstructuredKinds, along with their
+// scope. Files don't say which resource a kind belongs to, so it is guessed from the kind the same way as kubectl does without discovery,
+// and kinds whose objects have a namespace are namespaced. Only the first version that is seen of each kind is returned.
+func unknownKinds(objs []runtime.Object) map[schema.GroupVersionKind]meta.RESTScope {
+	kinds := make(map[schema.GroupVersionKind]meta.RESTScope)
+	seen := make(map[schema.GroupKind]bool)
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		gvk := u.GroupVersionKind()
+		if _, ok := offlineUnstructuredKinds[gvk]; ok || seen[gvk.GroupKind()] || scheme.Scheme.Recognizes(gvk) {
+			continue
+		}
+		seen[gvk.GroupKind()] = true
+		kinds[gvk] = meta.RESTScopeRoot
+		if u.GetNamespace() != "" {
+			kinds[gvk] = meta.RESTScopeNamespace
+		}
+	}
+	return kinds
+}
+
+// ReadObjectsFromFile reads every Kubernetes object in a JSON or YAML file, a filename of "-" reads from stdin instead
+func ReadObjectsFromFile(filename string, stdin io.Reader) ([]runtime.Object, error) {
+	if filename == StdinFilename {
+		return ReadObjects(stdin)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadObjects(f)
+}
+
+// ReadObjects decodes every Kubernetes object in a stream of JSON or YAML documents (YAML documents may be separated with ---). Lists,
+// such as PodList, ResourceQuotaList, or the generic List that kubectl get -o json produces, are flattened into their items. Kinds
+// that aren't in client-go's scheme, such as ClusterResourceQuotas or custom resources that quotas count, are kept as unstructured
+// objects.
+func ReadObjects(r io.Reader) ([]runtime.Object, error) {
+	objs := make([]runtime.Object, 0)
+	decoder := yaml.NewYAMLOrJSONDecoder(r, yamlOrJSONBufferSize)
+	for {
+		var raw runtime.RawExtension
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+			continue
+		}
+
+		docObjs, err := decodeObjects(raw.Raw)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, docObjs...)
+	}
+}
+
+func decodeObjects(data []byte) ([]runtime.Object, error) {
+	obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) && gvk != nil && gvk.Kind != "" {
+			return decodeUnstructured(data)
+		}
+		if runtime.IsNotRegisteredError(err) {
+			klog.V(2).Infof("Skipping object of unknown kind: %v", err)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !meta.IsListType(obj) {
+		return []runtime.Object{obj}, nil
+	}
+
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract items from %s: %w", gvk.Kind, err)
+	}
+	objs := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		// Items of a generic List are left undecoded, so decode them individually
+		if u, ok := item.(*runtime.Unknown); ok {
+			itemObjs, err := decodeObjects(u.Raw)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, itemObjs...)
+			continue
+		}
+		objs = append(objs, item)
+	}
+
+	return objs, nil
+}
+
+// decodeUnstructured decodes an object, or a list of objects, of a kind that isn't in client-go's scheme
+func decodeUnstructured(data []byte) ([]runtime.Object, error) {
+	obj, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ul, ok := obj.(*unstructured.UnstructuredList)
+	if !ok {
+		return []runtime.Object{obj}, nil
+	}
+
+	objs := make([]runtime.Object, 0, len(ul.Items))
+	for idx := range ul.Items {
+		objs = append(objs, &ul.Items[idx])
+	}
+	return objs, nil
+}
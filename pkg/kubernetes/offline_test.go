@@ -0,0 +1,326 @@
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	podListJSON = `{
+  "apiVersion": "v1",
+  "kind": "PodList",
+  "items": [
+    {"metadata": {"name": "a", "namespace": "ns"}},
+    {"metadata": {"name": "b", "namespace": "ns"}}
+  ]
+}`
+	genericListJSON = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "a", "namespace": "ns"}},
+    {"apiVersion": "v1", "kind": "ResourceQuota", "metadata": {"name": "q", "namespace": "ns"}},
+    {"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w", "namespace": "ns"}}
+  ]
+}`
+	multiDocYAML = `apiVersion: v1
+kind: Pod
+metadata:
+  name: a
+  namespace: ns
+---
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+  namespace: ns
+---
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata:
+  name: team
+`
+)
+
+// objectNames describes every object as kind/name so that decoded objects can be compared
+func objectNames(t *testing.T, objs []runtime.Object) []string {
+	t.Helper()
+	names := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			t.Fatalf("object without metadata: %v", err)
+		}
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil {
+			kind = gvks[0].Kind
+		}
+		names = append(names, kind+"/"+accessor.GetName())
+	}
+	return names
+}
+
+func TestReadObjects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "pod list",
+			input: podListJSON,
+			want:  []string{"Pod/a", "Pod/b"},
+		},
+		{
+			name:  "generic list keeps unknown kinds",
+			input: genericListJSON,
+			want:  []string{"Pod/a", "ResourceQuota/q", "Widget/w"},
+		},
+		{
+			name:  "multiple yaml documents",
+			input: multiDocYAML,
+			want:  []string{"Pod/a", "PersistentVolumeClaim/data", "ClusterResourceQuota/team"},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := ReadObjects(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadObjects() error = %v", err)
+			}
+			if got := objectNames(t, objs); !slices.Equal(got, tt.want) {
+				t.Errorf("ReadObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadObjectsInvalid(t *testing.T) {
+	_, err := ReadObjects(strings.NewReader("kind: [unterminated"))
+	if err == nil {
+		t.Error("ReadObjects() expected an error for invalid YAML")
+	}
+}
+
+func TestReadObjectsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pods.json")
+	if err := os.WriteFile(path, []byte(podListJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		stdin    string
+		want     []string
+	}{
+		{name: "file", filename: path, stdin: multiDocYAML, want: []string{"Pod/a", "Pod/b"}},
+		{name: "stdin", filename: StdinFilename, stdin: multiDocYAML,
+			want: []string{"Pod/a", "PersistentVolumeClaim/data", "ClusterResourceQuota/team"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := ReadObjectsFromFile(tt.filename, strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatalf("ReadObjectsFromFile() error = %v", err)
+			}
+			if got := objectNames(t, objs); !slices.Equal(got, tt.want) {
+				t.Errorf("ReadObjectsFromFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadObjectsFromFile(filepath.Join(t.TempDir(), "missing.yaml"), nil); err == nil {
+		t.Error("ReadObjectsFromFile() expected an error for a missing file")
+	}
+}
+
+func TestOfflineRESTMapperScopes(t *testing.T) {
+	mapper := offlineRESTMapper()
+	tests := []struct {
+		gk   schema.GroupKind
+		want meta.RESTScopeName
+	}{
+		{gk: schema.GroupKind{Kind: "Pod"}, want: meta.RESTScopeNameNamespace},
+		{gk: schema.GroupKind{Kind: "ResourceQuota"}, want: meta.RESTScopeNameNamespace},
+		{gk: schema.GroupKind{Kind: "Namespace"}, want: meta.RESTScopeNameRoot},
+		{gk: schema.GroupKind{Kind: "Node"}, want: meta.RESTScopeNameRoot},
+		{gk: schema.GroupKind{Kind: "PersistentVolume"}, want: meta.RESTScopeNameRoot},
+		{gk: schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}, want: meta.RESTScopeNameNamespace},
+		{gk: schema.GroupKind{Group: "storage.k8s.io", Kind: "StorageClass"}, want: meta.RESTScopeNameRoot},
+		{gk: schema.GroupKind{Group: "scheduling.k8s.io", Kind: "PriorityClass"}, want: meta.RESTScopeNameRoot},
+		{gk: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}, want: meta.RESTScopeNameRoot},
+		{gk: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "Role"}, want: meta.RESTScopeNameNamespace},
+		{gk: clusterResourceQuotaGVK.GroupKind(), want: meta.RESTScopeNameRoot},
+		{gk: appliedClusterResourceQuotaGVK.GroupKind(), want: meta.RESTScopeNameNamespace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.gk.String(), func(t *testing.T) {
+			mapping, err := mapper.RESTMapping(tt.gk)
+			if err != nil {
+				t.Fatalf("RESTMapping() error = %v", err)
+			}
+			if got := mapping.Scope.Name(); got != tt.want {
+				t.Errorf("scope = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientForObjects(t *testing.T) {
+	ctx := context.Background()
+	pod := func(ns, name, app, node string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{"app": app}},
+			Spec:       v1.PodSpec{NodeName: node},
+		}
+	}
+	objs, err := ReadObjects(strings.NewReader(multiDocYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs = append(objs,
+		pod("ns", "web-1", "web", "node1"),
+		pod("ns", "web-2", "web", "node2"),
+		pod("ns", "db", "db", "node1"),
+		pod("other", "web-1", "web", "node1"),
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}},
+		&v1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "ns"}},
+	)
+	c, err := NewClientForObjects(objs...)
+	if err != nil {
+		t.Fatalf("NewClientForObjects() error = %v", err)
+	}
+
+	podTests := []struct {
+		name     string
+		ns       string
+		selector PodSelector
+		want     []string
+	}{
+		{name: "namespace", ns: "ns", want: []string{"a", "web-1", "web-2", "db"}},
+		{name: "all namespaces", ns: "", want: []string{"a", "web-1", "web-2", "db", "web-1"}},
+		{name: "label selector", ns: "ns", selector: PodSelector{Labels: "app=web"}, want: []string{"web-1", "web-2"}},
+		{name: "field selector", ns: "ns", selector: PodSelector{Fields: "spec.nodeName=node1"}, want: []string{"web-1", "db"}},
+		{name: "both selectors", ns: "ns", selector: PodSelector{Labels: "app=web", Fields: "spec.nodeName!=node1"},
+			want: []string{"web-2"}},
+	}
+	for _, tt := range podTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			err := c.ForEachPod(ctx, tt.ns, tt.selector, func(pod *v1.Pod) error {
+				got = append(got, pod.Name)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ForEachPod() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ForEachPod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	nsl, err := c.ListNamespaces(ctx)
+	if err != nil || len(nsl.Items) != 1 || nsl.Items[0].Name != "ns" {
+		t.Errorf("ListNamespaces() = %v, %v, want the ns namespace", nsl, err)
+	}
+
+	if _, err := c.FindQuotasByNSAndName(ctx, "ns", "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("FindQuotasByNSAndName() error = %v, want not found", err)
+	}
+	rqs, err := c.FindQuotasByNSAndName(ctx, "ns", "q")
+	if err != nil || len(rqs) != 1 || rqs[0].Name != "q" {
+		t.Errorf("FindQuotasByNSAndName() = %v, %v, want quota q", rqs, err)
+	}
+
+	crqs, err := c.ListClusterResourceQuotas(ctx, "")
+	if err != nil || len(crqs) != 1 || crqs[0].Name != "team" {
+		t.Errorf("ListClusterResourceQuotas() = %v, %v, want quota team", crqs, err)
+	}
+
+	if _, err := c.Interface().Discovery().ServerVersion(); err != nil {
+		t.Errorf("ServerVersion() error = %v", err)
+	}
+
+	count, err := c.CountObjects(ctx, "ns", schema.GroupResource{Resource: "persistentvolumeclaims"})
+	if err != nil || count != 1 {
+		t.Errorf("CountObjects() = %d, %v, want 1", count, err)
+	}
+	if _, err := c.CountObjects(ctx, "ns", schema.GroupResource{Group: "example.com", Resource: "widgets"}); !meta.IsNoMatchError(err) {
+		t.Errorf("CountObjects() error = %v, want no match", err)
+	}
+
+	// Only the metadata is listed, but the objects still carry their own kind
+	var kinds []string
+	claims := schema.GroupResource{Resource: "persistentvolumeclaims"}
+	err = c.ForEachObjectMetadata(ctx, "ns", claims, func(obj *metav1.PartialObjectMetadata) error {
+		kinds = append(kinds, obj.APIVersion+"/"+obj.Kind+"/"+obj.Name)
+		return nil
+	})
+	if err != nil || !slices.Equal(kinds, []string{"v1/PersistentVolumeClaim/data"}) {
+		t.Errorf("ForEachObjectMetadata() = %v, %v, want the data claim", kinds, err)
+	}
+}
+
+func TestNewClientForObjectsUnknownKinds(t *testing.T) {
+	objs, err := ReadObjects(strings.NewReader(genericListJSON + `
+{"apiVersion": "example.com/v1", "kind": "WidgetList", "items": [
+  {"metadata": {"name": "w2", "namespace": "ns"}},
+  {"metadata": {"name": "w3", "namespace": "other"}}
+]}
+{"apiVersion": "example.com/v2", "kind": "Widget", "metadata": {"name": "w4", "namespace": "ns"}}
+{"apiVersion": "example.com/v1", "kind": "Gadget", "metadata": {"name": "g"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClientForObjects(objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ns   string
+		gr   schema.GroupResource
+		want int64
+	}{
+		// Other versions of a kind are served by the version that was seen first
+		{ns: "ns", gr: schema.GroupResource{Group: "example.com", Resource: "widgets"}, want: 3},
+		{ns: "other", gr: schema.GroupResource{Group: "example.com", Resource: "widgets"}, want: 1},
+		{ns: "", gr: schema.GroupResource{Group: "example.com", Resource: "gadgets"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ns+"/"+tt.gr.String(), func(t *testing.T) {
+			count, err := c.CountObjects(context.Background(), tt.ns, tt.gr)
+			if err != nil || count != tt.want {
+				t.Errorf("CountObjects() = %d, %v, want %d", count, err, tt.want)
+			}
+		})
+	}
+
+	mapping, err := c.mapper.RESTMapping(schema.GroupKind{Group: "example.com", Kind: "Gadget"})
+	if err != nil || mapping.Scope.Name() != meta.RESTScopeNameRoot {
+		t.Errorf("RESTMapping() = %v, %v, want a cluster scoped gadgets resource", mapping, err)
+	}
+}
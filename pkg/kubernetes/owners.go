@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	ownerKindPod = "Pod"
+)
+
+// Owner identifies the top level controller that owns a pod, such as a Deployment or a CronJob. Pods without a controller are their
+// own owner.
+type Owner struct {
+	Kind string
+	Name string
+}
+
+func (o Owner) String() string {
+	return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+}
+
+// OwnerResolver follows the controller references of pods up to their top level controller: ReplicaSets up to their Deployment and
+// Jobs up to their CronJob. StatefulSets, DaemonSets and any other controllers own their pods directly.
+type OwnerResolver struct {
+	// parents maps the UID of every intermediate controller (ReplicaSets and Jobs) to its own controller, if it has one
+	parents map[types.UID]*metav1.OwnerReference
+}
+
+func (c *Client) ListReplicaSetsByNS(ctx context.Context, ns string) (*appsv1.ReplicaSetList, error) {
+	return c.k8s.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{})
+}
+
+func (c *Client) ListJobsByNS(ctx context.Context, ns string) (*batchv1.JobList, error) {
+	return c.k8s.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{})
+}
+
+// NewOwnerResolver lists the ReplicaSets and Jobs of a namespace once, so that any number of pods can be resolved without further
+// lookups
+func (c *Client) NewOwnerResolver(ctx context.Context, ns string) (*OwnerResolver, error) {
+	r := OwnerResolver{
+		parents: make(map[types.UID]*metav1.OwnerReference),
+	}
+
+	rsl, err := c.ListReplicaSetsByNS(ctx, ns)
+	if err != nil {
+		return nil, fmt.Errorf("could not list replicasets: %w", err)
+	}
+	for idx := range rsl.Items {
+		r.addParent(&rsl.Items[idx])
+	}
+
+	jl, err := c.ListJobsByNS(ctx, ns)
+	if err != nil {
+		return nil, fmt.Errorf("could not list jobs: %w", err)
+	}
+	for idx := range jl.Items {
+		r.addParent(&jl.Items[idx])
+	}
+
+	return &r, nil
+}
+
+func (r *OwnerResolver) addParent(obj metav1.Object) {
+	if ref := metav1.GetControllerOf(obj); ref != nil {
+		r.parents[obj.GetUID()] = ref
+	}
+}
+
+// Resolve returns the top level controller of the object (most commonly a pod)
+func (r *OwnerResolver) Resolve(obj metav1.Object) Owner {
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil {
+		return Owner{Kind: ownerKindPod, Name: obj.GetName()}
+	}
+
+	for {
+		parent, ok := r.parents[ref.UID]
+		if !ok {
+			return Owner{Kind: ref.Kind, Name: ref.Name}
+		}
+		ref = parent
+	}
+}
+
+// OwnerAccessChecks returns the permissions that NewOwnerResolver needs
+func OwnerAccessChecks(ns string) []AccessCheck {
+	return []AccessCheck{
+		{Verb: "list", Group: "apps", Resource: "replicasets", Namespace: ns},
+		{Verb: "list", Group: "batch", Resource: "jobs", Namespace: ns},
+	}
+}
@@ -0,0 +1,65 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// controlledBy returns object metadata in the ns namespace, controlled by the owner when one is given
+func controlledBy(name string, uid types.UID, owner *Owner, ownerUID types.UID) metav1.ObjectMeta {
+	om := metav1.ObjectMeta{Name: name, Namespace: "ns", UID: uid}
+	if owner != nil {
+		controller := true
+		om.OwnerReferences = []metav1.OwnerReference{{Kind: owner.Kind, Name: owner.Name, UID: ownerUID, Controller: &controller}}
+	}
+	return om
+}
+
+func TestOwnerResolverResolve(t *testing.T) {
+	deployment := &Owner{Kind: "Deployment", Name: "web"}
+	replicaSet := &Owner{Kind: "ReplicaSet", Name: "web-5d8f"}
+	cronJob := &Owner{Kind: "CronJob", Name: "backup"}
+	job := &Owner{Kind: "Job", Name: "backup-2891"}
+	statefulSet := &Owner{Kind: "StatefulSet", Name: "db"}
+
+	cs := fake.NewSimpleClientset(
+		&appsv1.ReplicaSet{ObjectMeta: controlledBy("web-5d8f", "rs-uid", deployment, "deploy-uid")},
+		&appsv1.ReplicaSet{ObjectMeta: controlledBy("bare-rs", "bare-rs-uid", nil, "")},
+		&batchv1.Job{ObjectMeta: controlledBy("backup-2891", "job-uid", cronJob, "cronjob-uid")},
+	)
+	r, err := NewClientForInterface(cs).NewOwnerResolver(context.Background(), "ns")
+	if err != nil {
+		t.Fatalf("NewOwnerResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pod  *v1.Pod
+		want Owner
+	}{
+		{name: "deployment", pod: &v1.Pod{ObjectMeta: controlledBy("web-5d8f-x", "", replicaSet, "rs-uid")}, want: *deployment},
+		{name: "cronjob", pod: &v1.Pod{ObjectMeta: controlledBy("backup-2891-x", "", job, "job-uid")}, want: *cronJob},
+		{name: "replicaset without a deployment", pod: &v1.Pod{ObjectMeta: controlledBy("bare-rs-x", "", &Owner{Kind: "ReplicaSet",
+			Name: "bare-rs"}, "bare-rs-uid")}, want: Owner{Kind: "ReplicaSet", Name: "bare-rs"}},
+		{name: "controller that owns its pods", pod: &v1.Pod{ObjectMeta: controlledBy("db-0", "", statefulSet, "sts-uid")},
+			want: *statefulSet},
+		// The pod still names its owner even though the owner itself is gone
+		{name: "owner that no longer exists", pod: &v1.Pod{ObjectMeta: controlledBy("old-x", "", &Owner{Kind: "ReplicaSet",
+			Name: "old"}, "gone-uid")}, want: Owner{Kind: "ReplicaSet", Name: "old"}},
+		{name: "bare pod", pod: &v1.Pod{ObjectMeta: controlledBy("debug", "", nil, "")}, want: Owner{Kind: "Pod", Name: "debug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Resolve(tt.pod); got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// podPageSize is the number of pods that are requested from the API server at a time
+const podPageSize = 500
+
+// PodSelector narrows down the pods that ForEachPod lists. Both selectors use the same syntax as kubectl's --selector and
+// --field-selector, and an empty selector matches every pod.
+type PodSelector struct {
+	Labels string
+	Fields string
+}
+
+// IsEmpty returns true if the selector matches every pod
+func (s PodSelector) IsEmpty() bool {
+	return s.Labels == "" && s.Fields == ""
+}
+
+// Validate parses both selectors so that mistakes are reported before any pods are listed
+func (s PodSelector) Validate() error {
+	_, err := labels.Parse(s.Labels)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	_, err = fields.ParseSelector(s.Fields)
+	if err != nil {
+		return fmt.Errorf("invalid field selector: %w", err)
+	}
+	return nil
+}
+
+func (s PodSelector) String() string {
+	parts := make([]string, 0, 2)
+	for _, sel := range []string{s.Labels, s.Fields} {
+		if sel != "" {
+			parts = append(parts, sel)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ForEachPod pages through the pods in a namespace that match the selector and calls fn for every one of them. Only a single page of
+// pods is held in memory at any given time, so this should be preferred over GetPodsByNamespace for namespaces that may be very large.
+// The pod passed to fn is only valid for the duration of the call.
+func (c *Client) ForEachPod(ctx context.Context, ns string, sel PodSelector, fn func(*v1.Pod) error) error {
+	opts := metav1.ListOptions{
+		Limit:         podPageSize,
+		LabelSelector: sel.Labels,
+		FieldSelector: sel.Fields,
+	}
+
+	for {
+		pl, err := c.k8s.CoreV1().Pods(ns).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		for idx := range pl.Items {
+			err = fn(&pl.Items[idx])
+			if err != nil {
+				return err
+			}
+		}
+
+		if pl.Continue == "" {
+			return nil
+		}
+		opts.Continue = pl.Continue
+	}
+}
+
+func (c *Client) GetPodsByNamespace(ctx context.Context, ns string) (*v1.PodList, error) {
+	pl := v1.PodList{}
+	err := c.ForEachPod(ctx, ns, PodSelector{}, func(pod *v1.Pod) error {
+		pl.Items = append(pl.Items, *pod)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pl, nil
+}
+
+// PodAccessChecks returns the permissions that ForEachPod and GetPodsByNamespace need
+func PodAccessChecks(ns string) []AccessCheck {
+	return []AccessCheck{{Verb: "list", Resource: "pods", Namespace: ns}}
+}
@@ -0,0 +1,55 @@
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestForEachPodPages(t *testing.T) {
+	pod := func(name string) v1.Pod {
+		return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}}
+	}
+	// Pages are keyed by the continue token that asks for them
+	pages := map[string]*v1.PodList{
+		"":       {ListMeta: metav1.ListMeta{Continue: "page-2"}, Items: []v1.Pod{pod("a"), pod("b")}},
+		"page-2": {Items: []v1.Pod{pod("c")}},
+	}
+
+	cs := fake.NewSimpleClientset()
+	requests := make([]metav1.ListOptions, 0)
+	cs.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		requests = append(requests, opts)
+		return true, pages[opts.Continue], nil
+	})
+
+	names := make([]string, 0)
+	sel := PodSelector{Fields: "status.phase=Running"}
+	err := NewClientForInterface(cs).ForEachPod(context.Background(), "ns", sel, func(pod *v1.Pod) error {
+		names = append(names, pod.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachPod() error = %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(names, want) {
+		t.Errorf("ForEachPod() = %v, want the pods of both pages %v", names, want)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("ForEachPod() made %d requests, want 2", len(requests))
+	}
+	for idx, want := range []string{"", "page-2"} {
+		opts := requests[idx]
+		if opts.Continue != want || opts.Limit != podPageSize || opts.FieldSelector != sel.Fields {
+			t.Errorf("request %d = %+v, want continue %q with a limit of %d and the field selector", idx, opts, want, podPageSize)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Client) ListQuotasByNS(ctx context.Context, ns string) (*v1.ResourceQuotaList, error) {
+	return c.k8s.CoreV1().ResourceQuotas(ns).List(ctx, metav1.ListOptions{})
+}
+
+// FindQuotasByNSAndName returns the quota with the given name, or every quota in the namespace sorted by name when no name is given
+func (c *Client) FindQuotasByNSAndName(ctx context.Context, ns, name string) ([]*v1.ResourceQuota, error) {
+	if name == "" {
+		rql, err := c.ListQuotasByNS(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+
+		quotas := make([]*v1.ResourceQuota, 0, len(rql.Items))
+		for idx := range rql.Items {
+			quotas = append(quotas, &rql.Items[idx])
+		}
+		return SelectQuotas(ns, quotas)
+	}
+
+	rq, err := c.k8s.CoreV1().ResourceQuotas(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []*v1.ResourceQuota{rq}, nil
+}
+
+// SelectQuotas returns all of the quotas in a namespace sorted by name, or an error if there aren't any
+func SelectQuotas(ns string, quotas []*v1.ResourceQuota) ([]*v1.ResourceQuota, error) {
+	if len(quotas) < 1 {
+		return nil, fmt.Errorf("no resource quotas existed in namespace %s, please try a different namespace", ns)
+	}
+
+	sort.Slice(quotas, func(i, j int) bool {
+		return quotas[i].Name < quotas[j].Name
+	})
+	return quotas, nil
+}
+
+// QuotaAccessChecks returns the permissions that FindQuotasByNSAndName needs
+func QuotaAccessChecks(ns, name string) []AccessCheck {
+	if name == "" {
+		return []AccessCheck{{Verb: "list", Resource: "resourcequotas", Namespace: ns}}
+	}
+	return []AccessCheck{{Verb: "get", Resource: "resourcequotas", Namespace: ns, Name: name}}
+}
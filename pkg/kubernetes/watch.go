@@ -0,0 +1,21 @@
+package kubernetes
+
+import (
+	"k8s.io/client-go/informers"
+)
+
+// NewInformerFactory returns a shared informer factory whose informers only watch objects in the given namespace
+func (c *Client) NewInformerFactory(ns string) informers.SharedInformerFactory {
+	return informers.NewSharedInformerFactoryWithOptions(c.k8s, 0, informers.WithNamespace(ns))
+}
+
+// WatchAccessChecks returns the permissions that informers on the given resources need within a namespace
+func WatchAccessChecks(ns string, resources ...string) []AccessCheck {
+	checks := make([]AccessCheck, 0, len(resources)*2)
+	for _, resource := range resources {
+		checks = append(checks,
+			AccessCheck{Verb: "list", Resource: resource, Namespace: ns},
+			AccessCheck{Verb: "watch", Resource: resource, Namespace: ns})
+	}
+	return checks
+}
@@ -5,14 +5,16 @@ import (
 
 	"github.com/aauren/kube-quota/pkg/kubernetes"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// GetPodsByNamespace lists every pod in a namespace using the default kubeconfig and its current context.
+//
+// Deprecated: build a kubernetes.Client once with kubernetes.NewClient and use its GetPodsByNamespace method instead.
 func GetPodsByNamespace(ctx context.Context, ns string) (*v1.PodList, error) {
-	k8s, err := kubernetes.GetClientSet()
+	c, err := kubernetes.NewClient(&kubernetes.ConfigOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	return k8s.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+	return c.GetPodsByNamespace(ctx, ns)
 }
@@ -1,44 +1,101 @@
 package quota
 
-func (t *NamespaceWorkloadQuota) Sum() *WorkloadQuota {
-	wl := WorkloadQuota{
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+func newEmptyWorkloadQuota() *WorkloadQuota {
+	return &WorkloadQuota{
 		Request: &ComputeQuota{},
 		Limit:   &ComputeQuota{},
 		StorageQuota: &StorageQuota{
 			Ephemeral:      &EphemeralQuota{},
 			StorageClasses: make(map[string]*StorageClassQuota),
 		},
+		Objects: make(map[v1.ResourceName]int64),
+	}
+}
+
+// Sum returns the total of every pod that was added to the namespace, along with any object counts that were set. The returned
+// WorkloadQuota is a copy and can safely be modified.
+func (t *NamespaceWorkloadQuota) Sum() *WorkloadQuota {
+	wl := newEmptyWorkloadQuota()
+	if t.total != nil {
+		wl.Add(t.total)
 	}
-	for _, a := range t.PodQuotas {
-		wl.Add(a.Sum())
+	for name, count := range t.objects {
+		wl.Objects[name] += count
 	}
-	return &wl
+	// Pods are always counted, even when there aren't any
+	if _, ok := wl.Objects[v1.ResourcePods]; !ok {
+		wl.Objects[v1.ResourcePods] = 0
+	}
+	return wl
 }
 
+// Sum returns the total of every container in the pod, the pod itself counts as a single pod object
 func (a *PodQuota) Sum() *WorkloadQuota {
-	wl := WorkloadQuota{
-		Request: &ComputeQuota{},
-		Limit:   &ComputeQuota{},
-		StorageQuota: &StorageQuota{
-			Ephemeral:      &EphemeralQuota{},
-			StorageClasses: make(map[string]*StorageClassQuota),
-		},
-	}
+	wl := newEmptyWorkloadQuota()
 	for _, q := range a.WorkloadQuotas {
 		wl.Add(q)
 	}
-	return &wl
+	wl.Objects[v1.ResourcePods] = 1
+	return wl
+}
+
+// SumWorkloadQuotas returns a new WorkloadQuota that holds the combined requests and limits of all of the passed WorkloadQuotas
+func SumWorkloadQuotas(wqs ...*WorkloadQuota) *WorkloadQuota {
+	wl := newEmptyWorkloadQuota()
+	for _, o := range wqs {
+		wl.Add(o)
+	}
+	return wl
 }
 
 func (w *WorkloadQuota) Add(o *WorkloadQuota) {
 	w.Request.Add(o.Request)
 	w.Limit.Add(o.Limit)
 	w.StorageQuota.Add(o.StorageQuota)
+	for name, val := range o.Objects {
+		if w.Objects == nil {
+			w.Objects = make(map[v1.ResourceName]int64)
+		}
+		w.Objects[name] += val
+	}
+}
+
+func (w *WorkloadQuota) Sub(o *WorkloadQuota) {
+	w.Request.Sub(o.Request)
+	w.Limit.Sub(o.Limit)
+	w.StorageQuota.Sub(o.StorageQuota)
+	for name, val := range o.Objects {
+		if w.Objects == nil {
+			w.Objects = make(map[v1.ResourceName]int64)
+		}
+		w.Objects[name] -= val
+	}
 }
 
 func (r *ComputeQuota) Add(o *ComputeQuota) {
 	r.CPU += o.CPU
 	r.Mem += o.Mem
+	for name, val := range o.Extended {
+		if r.Extended == nil {
+			r.Extended = make(map[v1.ResourceName]int64)
+		}
+		r.Extended[name] += val
+	}
+}
+
+func (r *ComputeQuota) Sub(o *ComputeQuota) {
+	r.CPU -= o.CPU
+	r.Mem -= o.Mem
+	for name, val := range o.Extended {
+		if r.Extended == nil {
+			r.Extended = make(map[v1.ResourceName]int64)
+		}
+		r.Extended[name] -= val
+	}
 }
 
 func (e *EphemeralQuota) Add(o *EphemeralQuota) {
@@ -46,21 +103,167 @@ func (e *EphemeralQuota) Add(o *EphemeralQuota) {
 	e.Limits += o.Limits
 }
 
+func (e *EphemeralQuota) Sub(o *EphemeralQuota) {
+	e.Requests -= o.Requests
+	e.Limits -= o.Limits
+}
+
 func (s *StorageClassQuota) Add(o *StorageClassQuota) {
 	s.Claims += o.Claims
 	s.Requests += o.Requests
 }
 
+func (s *StorageClassQuota) Sub(o *StorageClassQuota) {
+	s.Claims -= o.Claims
+	s.Requests -= o.Requests
+}
+
 func (s *StorageQuota) Add(o *StorageQuota) {
 	s.Ephemeral.Add(o.Ephemeral)
+	if o.AllClasses != nil {
+		if s.AllClasses == nil {
+			s.AllClasses = &StorageClassQuota{}
+		}
+		s.AllClasses.Add(o.AllClasses)
+	}
 	for scKey, scVal := range o.StorageClasses {
 		sc, ok := s.StorageClasses[scKey]
 		if !ok {
 			sc = &StorageClassQuota{
-				Name: sc.Name,
+				Name: scVal.Name,
 			}
 			s.StorageClasses[scKey] = sc
 		}
 		sc.Add(scVal)
 	}
 }
+
+func (s *StorageQuota) Sub(o *StorageQuota) {
+	s.Ephemeral.Sub(o.Ephemeral)
+	if o.AllClasses != nil {
+		if s.AllClasses == nil {
+			s.AllClasses = &StorageClassQuota{}
+		}
+		s.AllClasses.Sub(o.AllClasses)
+	}
+	for scKey, scVal := range o.StorageClasses {
+		sc, ok := s.StorageClasses[scKey]
+		if !ok {
+			sc = &StorageClassQuota{
+				Name: scVal.Name,
+			}
+			s.StorageClasses[scKey] = sc
+		}
+		sc.Sub(scVal)
+	}
+}
+
+// Add adds the hard limits (and usage from the status) of another quota to this one, for instance to total the quotas for the same
+// namespace across clusters
+func (k *KubeQuota) Add(o *KubeQuota) {
+	for key, val := range o.Hard {
+		cur := k.Hard[key]
+		cur.Add(val)
+		k.Hard[key] = cur
+	}
+	if o.HasStatus() {
+		if !k.HasStatus() {
+			k.Used = v1.ResourceList{}
+		}
+		for key, val := range o.Used {
+			cur := k.Used[key]
+			cur.Add(val)
+			k.Used[key] = cur
+		}
+	}
+	if o.StatusHard != nil {
+		if k.StatusHard == nil {
+			k.StatusHard = v1.ResourceList{}
+		}
+		for key, val := range o.StatusHard {
+			cur := k.StatusHard[key]
+			cur.Add(val)
+			k.StatusHard[key] = cur
+		}
+	}
+	k.WQ.Request.Add(o.WQ.Request)
+	k.WQ.Limit.Add(o.WQ.Limit)
+	for name, val := range o.WQ.Objects {
+		if k.WQ.Objects == nil {
+			k.WQ.Objects = make(map[v1.ResourceName]int64)
+		}
+		k.WQ.Objects[name] += val
+	}
+	if o.HasEphemeralQuota() {
+		if !k.HasEphemeralQuota() {
+			k.SQ.Ephemeral = &EphemeralQuota{}
+		}
+		k.SQ.Ephemeral.Add(o.SQ.Ephemeral)
+	}
+	if o.SQ.HasPersistentQuota() {
+		if !k.SQ.HasPersistentQuota() {
+			k.SQ.AllClasses = &StorageClassQuota{}
+		}
+		k.SQ.AllClasses.Add(o.SQ.AllClasses)
+	}
+	for class, sc := range o.SQ.StorageClasses {
+		if _, ok := k.SQ.StorageClasses[class]; !ok {
+			k.SQ.StorageClasses[class] = &StorageClassQuota{Name: sc.Name}
+		}
+		k.SQ.StorageClasses[class].Add(sc)
+	}
+}
+
+// SumKubeQuotas returns a new quota that holds the combined hard limits of all of the passed quotas
+func SumKubeQuotas(kqs ...*KubeQuota) *KubeQuota {
+	kq := KubeQuota{
+		Hard: v1.ResourceList{},
+		WQ: &WorkloadQuota{
+			Request: &ComputeQuota{},
+			Limit:   &ComputeQuota{},
+		},
+		SQ: &StorageQuota{
+			StorageClasses: make(map[string]*StorageClassQuota),
+		},
+	}
+	for _, o := range kqs {
+		kq.Add(o)
+	}
+	return &kq
+}
+
+// Snapshot returns a copy of the namespace's total that shares no state with it, so that it can be read while the namespace keeps
+// changing. The individual pods aren't copied.
+func (t *NamespaceWorkloadQuota) Snapshot() *NamespaceWorkloadQuota {
+	nq := NewNamespaceWorkloadQuota(t.Namespace, false)
+	nq.Name = t.Name
+	nq.total = t.Sum()
+	return nq
+}
+
+// MergeNamespaceWorkloadQuotas returns a new NamespaceWorkloadQuota that holds the totals of all of the passed NamespaceWorkloadQuotas.
+// Individual pods are only kept if every one of the passed NamespaceWorkloadQuotas kept theirs.
+func MergeNamespaceWorkloadQuotas(nqs ...*NamespaceWorkloadQuota) *NamespaceWorkloadQuota {
+	keepPods := true
+	for _, o := range nqs {
+		keepPods = keepPods && o.keepPods
+	}
+
+	nq := NewNamespaceWorkloadQuota("", keepPods)
+	for _, o := range nqs {
+		if !keepPods {
+			nq.total.Add(o.Sum())
+			continue
+		}
+		for _, pq := range o.PodQuotas {
+			nq.AddPodQuota(pq)
+		}
+		for key, cq := range o.claims {
+			nq.addClaimQuota(key, cq)
+		}
+		for name, count := range o.objects {
+			nq.objects[name] += count
+		}
+	}
+	return nq
+}
@@ -0,0 +1,71 @@
+package quota
+
+import (
+	kubequota "github.com/aauren/kube-quota/pkg"
+	v1 "k8s.io/api/core/v1"
+)
+
+// QuotaForClaim returns the persistent storage that a PersistentVolumeClaim is charged for. Just like quota admission, every claim
+// counts no matter whether it is bound yet, and it is charged both across all storage classes and against its own storage class. When
+// a claim has been expanded, the larger of its requested and allocated storage is charged.
+func QuotaForClaim(pvc *v1.PersistentVolumeClaim) *WorkloadQuota {
+	storage := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if allocated, ok := pvc.Status.AllocatedResources[v1.ResourceStorage]; ok && allocated.Cmp(storage) > 0 {
+		storage = allocated
+	}
+	sc := StorageClassQuota{
+		Requests: kubequota.StorageBytes(storage.Value()),
+		Claims:   1,
+	}
+
+	wq := newEmptyWorkloadQuota()
+	wq.Name = pvc.Name
+	wq.StorageQuota.AllClasses = &StorageClassQuota{}
+	wq.StorageQuota.AllClasses.Add(&sc)
+	if class := claimStorageClass(pvc); class != "" {
+		wq.StorageQuota.StorageClasses[class] = &StorageClassQuota{Name: class}
+		wq.StorageQuota.StorageClasses[class].Add(&sc)
+	}
+
+	return wq
+}
+
+// claimStorageClass returns the storage class of a claim, preferring the beta annotation over the spec the same way that Kubernetes does
+func claimStorageClass(pvc *v1.PersistentVolumeClaim) string {
+	if class, ok := pvc.Annotations[v1.BetaStorageClassAnnotation]; ok {
+		return class
+	}
+	if pvc.Spec.StorageClassName != nil {
+		return *pvc.Spec.StorageClassName
+	}
+	return ""
+}
+
+// AddClaim adds the storage that a PersistentVolumeClaim reserves to the namespace total
+func (t *NamespaceWorkloadQuota) AddClaim(pvc *v1.PersistentVolumeClaim) {
+	t.addClaimQuota(objectKey(pvc.Namespace, pvc.Name), QuotaForClaim(pvc))
+}
+
+func (t *NamespaceWorkloadQuota) addClaimQuota(key string, cq *WorkloadQuota) {
+	t.total.Add(cq)
+	if t.keepPods {
+		t.claims[key] = cq
+	}
+}
+
+// UpdateClaim adds the claim to the namespace, replacing any version of the same claim that was added before. Replacing claims only
+// works for namespaces that keep their pods.
+func (t *NamespaceWorkloadQuota) UpdateClaim(pvc *v1.PersistentVolumeClaim) {
+	t.DeleteClaim(pvc.Namespace, pvc.Name)
+	t.AddClaim(pvc)
+}
+
+// DeleteClaim removes a claim that was previously added from the namespace. Deleting claims only works for namespaces that keep their
+// pods.
+func (t *NamespaceWorkloadQuota) DeleteClaim(ns, name string) {
+	key := objectKey(ns, name)
+	if cq, ok := t.claims[key]; ok {
+		t.total.Sub(cq)
+		delete(t.claims, key)
+	}
+}
@@ -0,0 +1,66 @@
+package quota
+
+import (
+	"slices"
+	"testing"
+
+	kubequota "github.com/aauren/kube-quota/pkg"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaForClaim(t *testing.T) {
+	gold, silver := "gold", "silver"
+	const gi = 1024 * 1024 * 1024
+
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		class        *string
+		requested    string
+		allocated    string
+		wantRequests kubequota.StorageBytes
+		wantClasses  []string
+	}{
+		{name: "requested", class: &gold, requested: "10Gi", wantRequests: 10 * gi, wantClasses: []string{"gold"}},
+		{name: "expanded", class: &gold, requested: "20Gi", allocated: "10Gi", wantRequests: 20 * gi, wantClasses: []string{"gold"}},
+		{name: "allocated more than requested", class: &gold, requested: "10Gi", allocated: "15Gi", wantRequests: 15 * gi,
+			wantClasses: []string{"gold"}},
+		{name: "beta annotation wins over the spec", annotations: map[string]string{v1.BetaStorageClassAnnotation: silver},
+			class: &gold, requested: "1Gi", wantRequests: gi, wantClasses: []string{"silver"}},
+		{name: "beta annotation without a spec", annotations: map[string]string{v1.BetaStorageClassAnnotation: silver},
+			requested: "1Gi", wantRequests: gi, wantClasses: []string{"silver"}},
+		{name: "no storage class", requested: "1Gi", wantRequests: gi, wantClasses: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvc := &v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Annotations: tt.annotations},
+				Spec: v1.PersistentVolumeClaimSpec{
+					StorageClassName: tt.class,
+					Resources:        v1.VolumeResourceRequirements{Requests: resources("storage", tt.requested)},
+				},
+			}
+			if tt.allocated != "" {
+				pvc.Status.AllocatedResources = resources("storage", tt.allocated)
+			}
+
+			wq := QuotaForClaim(pvc)
+			all := wq.StorageQuota.AllClasses
+			if all == nil || all.Requests != tt.wantRequests || all.Claims != 1 {
+				t.Errorf("all classes = %+v, want %d bytes in 1 claim", all, tt.wantRequests)
+			}
+			classes := make([]string, 0)
+			for class, sc := range wq.StorageQuota.StorageClasses {
+				classes = append(classes, class)
+				if sc.Requests != tt.wantRequests || sc.Claims != 1 {
+					t.Errorf("class %s = %+v, want %d bytes in 1 claim", class, sc, tt.wantRequests)
+				}
+			}
+			if !slices.Equal(classes, tt.wantClasses) {
+				t.Errorf("storage classes = %v, want %v", classes, tt.wantClasses)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package quota
+
+import (
+	"github.com/aauren/kube-quota/pkg/unit"
+)
+
+type headerValuer interface {
+	ValueForHeader(string) (unit.UnitWriter, error)
+}
+
+// Delta annotates every value of another HeaderValuer with how much the workload totals changed, for instance since the table was last
+// rendered. Values whose workload totals didn't change are left as they are.
+type Delta struct {
+	Value  headerValuer
+	Change *WorkloadQuota
+}
+
+// NewDelta returns a Delta for the value with the change between the previous and current workload totals
+func NewDelta(value headerValuer, previous, current *WorkloadQuota) *Delta {
+	change := newEmptyWorkloadQuota()
+	change.Add(current)
+	change.Sub(previous)
+	return &Delta{Value: value, Change: change}
+}
+
+func (d *Delta) ValueForHeader(hdr string) (unit.UnitWriter, error) {
+	val, err := d.Value.ValueForHeader(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	unchanged, err := d.Change.isZero(hdr)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		return val, nil
+	}
+
+	change, err := d.Change.ValueForHeader(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	return unit.NewDeltaWriter(val, change), nil
+}
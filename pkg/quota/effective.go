@@ -0,0 +1,100 @@
+package quota
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// InitContainersName names the WorkloadQuota that holds the amount that a pod's init containers raise its effective requests and
+	// limits above the sum of its long running containers
+	InitContainersName = "(init containers)"
+	// PodOverheadName names the WorkloadQuota that holds a pod's overhead, as set by its RuntimeClass
+	PodOverheadName = "(pod overhead)"
+)
+
+// isSidecar returns true for restartable init containers (native sidecars), which keep running alongside the app containers
+func isSidecar(cnt *v1.Container) bool {
+	return cnt.RestartPolicy != nil && *cnt.RestartPolicy == v1.ContainerRestartPolicyAlways
+}
+
+// initContainerExtra returns how much the init containers of a pod raise its effective resources above the sum of its app containers
+// and sidecars. This follows the same rules that the scheduler and quota admission use:
+//   - app containers and sidecars all run at the same time, so they are summed
+//   - regular init containers run one at a time, each one alongside any sidecars that were started before it
+//   - the pod's effective resource is the larger of the two, resource by resource
+//
+// The resources that are looked at (requests or limits) are chosen by get.
+func initContainerExtra(pod *v1.Pod, get func(v1.ResourceRequirements) v1.ResourceList) v1.ResourceList {
+	sum := v1.ResourceList{}
+	for idx := range pod.Spec.Containers {
+		addResourceList(sum, get(pod.Spec.Containers[idx].Resources))
+	}
+
+	sidecars := v1.ResourceList{}
+	initMax := v1.ResourceList{}
+	for idx := range pod.Spec.InitContainers {
+		cnt := &pod.Spec.InitContainers[idx]
+		running := v1.ResourceList{}
+		if isSidecar(cnt) {
+			addResourceList(sum, get(cnt.Resources))
+			addResourceList(sidecars, get(cnt.Resources))
+			addResourceList(running, sidecars)
+		} else {
+			addResourceList(running, get(cnt.Resources))
+			addResourceList(running, sidecars)
+		}
+		maxResourceList(initMax, running)
+	}
+
+	extra := v1.ResourceList{}
+	for name, initQuantity := range initMax {
+		sumQuantity := sum[name]
+		if initQuantity.Cmp(sumQuantity) > 0 {
+			q := initQuantity.DeepCopy()
+			q.Sub(sumQuantity)
+			extra[name] = q
+		}
+	}
+
+	return extra
+}
+
+// overheadLimits returns the pod overhead that applies to a pod's limits. Overhead is only added to resources that one of the pod's
+// containers sets a limit for, so that pods without limits don't suddenly gain one.
+func overheadLimits(pod *v1.Pod) v1.ResourceList {
+	ol := v1.ResourceList{}
+	for name, q := range pod.Spec.Overhead {
+		if hasLimit(pod.Spec.Containers, name) || hasLimit(pod.Spec.InitContainers, name) {
+			ol[name] = q.DeepCopy()
+		}
+	}
+	return ol
+}
+
+func hasLimit(cnts []v1.Container, name v1.ResourceName) bool {
+	for idx := range cnts {
+		if _, ok := cnts[idx].Resources.Limits[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func addResourceList(list, other v1.ResourceList) {
+	for name, q := range other {
+		if cur, ok := list[name]; ok {
+			cur.Add(q)
+			list[name] = cur
+			continue
+		}
+		list[name] = q.DeepCopy()
+	}
+}
+
+func maxResourceList(list, other v1.ResourceList) {
+	for name, q := range other {
+		if cur, ok := list[name]; !ok || q.Cmp(cur) > 0 {
+			list[name] = q.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package quota
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// resources builds a ResourceList from pairs of resource names and quantities
+func resources(pairs ...string) v1.ResourceList {
+	rl := v1.ResourceList{}
+	for idx := 0; idx+1 < len(pairs); idx += 2 {
+		rl[v1.ResourceName(pairs[idx])] = resource.MustParse(pairs[idx+1])
+	}
+	return rl
+}
+
+func container(name string, requests, limits v1.ResourceList) v1.Container {
+	return v1.Container{Name: name, Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}}
+}
+
+func sidecar(name string, requests, limits v1.ResourceList) v1.Container {
+	always := v1.ContainerRestartPolicyAlways
+	cnt := container(name, requests, limits)
+	cnt.RestartPolicy = &always
+	return cnt
+}
+
+func assertResourceList(t *testing.T, got, want v1.ResourceList) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+		return
+	}
+	for name, q := range want {
+		if g, ok := got[name]; !ok || g.Cmp(q) != 0 {
+			t.Errorf("got %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+func TestInitContainerExtra(t *testing.T) {
+	requests := func(rr v1.ResourceRequirements) v1.ResourceList { return rr.Requests }
+	limits := func(rr v1.ResourceRequirements) v1.ResourceList { return rr.Limits }
+
+	tests := []struct {
+		name string
+		get  func(v1.ResourceRequirements) v1.ResourceList
+		init []v1.Container
+		app  []v1.Container
+		want v1.ResourceList
+	}{
+		{
+			name: "no init containers",
+			get:  requests,
+			app:  []v1.Container{container("app", resources("cpu", "1"), nil)},
+			want: v1.ResourceList{},
+		},
+		{
+			name: "largest init container above the sum of the app containers",
+			get:  requests,
+			init: []v1.Container{
+				container("small", resources("cpu", "1", "memory", "64Mi"), nil),
+				container("large", resources("cpu", "2", "memory", "128Mi"), nil),
+			},
+			app: []v1.Container{
+				container("app", resources("cpu", "1", "memory", "128Mi"), nil),
+				container("proxy", resources("cpu", "500m", "memory", "128Mi"), nil),
+			},
+			want: resources("cpu", "500m"),
+		},
+		{
+			name: "init containers below the sum of the app containers",
+			get:  requests,
+			init: []v1.Container{container("init", resources("cpu", "1"), nil)},
+			app: []v1.Container{
+				container("app", resources("cpu", "1"), nil),
+				container("proxy", resources("cpu", "500m"), nil),
+			},
+			want: v1.ResourceList{},
+		},
+		{
+			name: "init container with a resource the app containers don't request",
+			get:  requests,
+			init: []v1.Container{container("init", resources("ephemeral-storage", "1Gi"), nil)},
+			app:  []v1.Container{container("app", resources("cpu", "1"), nil)},
+			want: resources("ephemeral-storage", "1Gi"),
+		},
+		{
+			name: "sidecar before a regular init container runs alongside it",
+			get:  requests,
+			init: []v1.Container{
+				sidecar("mesh", resources("cpu", "500m"), nil),
+				container("migrate", resources("cpu", "2"), nil),
+			},
+			app:  []v1.Container{container("app", resources("cpu", "1"), nil)},
+			want: resources("cpu", "1"),
+		},
+		{
+			name: "sidecar after a regular init container doesn't run alongside it",
+			get:  requests,
+			init: []v1.Container{
+				container("migrate", resources("cpu", "2"), nil),
+				sidecar("mesh", resources("cpu", "500m"), nil),
+			},
+			app:  []v1.Container{container("app", resources("cpu", "1"), nil)},
+			want: resources("cpu", "500m"),
+		},
+		{
+			name: "sidecars alone are part of the sum",
+			get:  requests,
+			init: []v1.Container{sidecar("mesh", resources("cpu", "500m"), nil)},
+			app:  []v1.Container{container("app", resources("cpu", "1"), nil)},
+			want: v1.ResourceList{},
+		},
+		{
+			name: "limits",
+			get:  limits,
+			init: []v1.Container{container("init", resources("cpu", "1"), resources("cpu", "4", "memory", "1Gi"))},
+			app:  []v1.Container{container("app", resources("cpu", "1"), resources("cpu", "2", "memory", "2Gi"))},
+			want: resources("cpu", "2"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{Spec: v1.PodSpec{InitContainers: tt.init, Containers: tt.app}}
+			assertResourceList(t, initContainerExtra(pod, tt.get), tt.want)
+		})
+	}
+}
+
+func TestOverheadLimits(t *testing.T) {
+	overhead := resources("cpu", "250m", "memory", "120Mi")
+
+	tests := []struct {
+		name     string
+		overhead v1.ResourceList
+		init     []v1.Container
+		app      []v1.Container
+		want     v1.ResourceList
+	}{
+		{
+			name: "no overhead",
+			app:  []v1.Container{container("app", nil, resources("cpu", "1", "memory", "1Gi"))},
+			want: v1.ResourceList{},
+		},
+		{
+			name:     "no limits",
+			overhead: overhead,
+			app:      []v1.Container{container("app", resources("cpu", "1", "memory", "1Gi"), nil)},
+			want:     v1.ResourceList{},
+		},
+		{
+			name:     "limits on every resource",
+			overhead: overhead,
+			app:      []v1.Container{container("app", nil, resources("cpu", "1", "memory", "1Gi"))},
+			want:     overhead,
+		},
+		{
+			name:     "limit on only some resources",
+			overhead: overhead,
+			app: []v1.Container{
+				container("app", nil, resources("memory", "1Gi")),
+				container("proxy", resources("cpu", "100m"), nil),
+			},
+			want: resources("memory", "120Mi"),
+		},
+		{
+			name:     "limit on an init container",
+			overhead: overhead,
+			init:     []v1.Container{container("init", nil, resources("cpu", "1"))},
+			app:      []v1.Container{container("app", resources("cpu", "1"), nil)},
+			want:     resources("cpu", "250m"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{Spec: v1.PodSpec{Overhead: tt.overhead, InitContainers: tt.init, Containers: tt.app}}
+			assertResourceList(t, overheadLimits(pod), tt.want)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package quota
+
+import (
+	"sort"
+	"strings"
+)
+
+// groupKeySeparator joins the keys of a group into a single map key, it can't appear in Kubernetes names
+const groupKeySeparator = "\x00"
+
+// GroupedWorkloadQuota sums WorkloadQuotas into groups that are identified by one or more keys, for instance the controller that owns a
+// set of pods, or the namespace, pod and container names of a single container. Only the sum of each group is kept so memory is bounded
+// by the number of groups rather than by the number of workloads that were added.
+type GroupedWorkloadQuota struct {
+	groups map[string]*workloadGroup
+}
+
+type workloadGroup struct {
+	keys []string
+	wq   *WorkloadQuota
+}
+
+func NewGroupedWorkloadQuota() *GroupedWorkloadQuota {
+	return &GroupedWorkloadQuota{
+		groups: make(map[string]*workloadGroup),
+	}
+}
+
+// Add adds the WorkloadQuota to the group identified by keys, creating the group if it doesn't exist yet
+func (g *GroupedWorkloadQuota) Add(wq *WorkloadQuota, keys ...string) {
+	key := strings.Join(keys, groupKeySeparator)
+	group, ok := g.groups[key]
+	if !ok {
+		group = &workloadGroup{
+			keys: keys,
+			wq:   newEmptyWorkloadQuota(),
+		}
+		group.wq.Name = strings.Join(keys, "/")
+		g.groups[key] = group
+	}
+	group.wq.Add(wq)
+}
+
+// Keys returns the keys of every group, sorted by each key in turn
+func (g *GroupedWorkloadQuota) Keys() [][]string {
+	keys := make([][]string, 0, len(g.groups))
+	for _, group := range g.groups {
+		keys = append(keys, group.keys)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.Join(keys[i], groupKeySeparator) < strings.Join(keys[j], groupKeySeparator)
+	})
+	return keys
+}
+
+// Get returns the sum of the group identified by keys, or nil if there is no such group
+func (g *GroupedWorkloadQuota) Get(keys ...string) *WorkloadQuota {
+	group, ok := g.groups[strings.Join(keys, groupKeySeparator)]
+	if !ok {
+		return nil
+	}
+	return group.wq
+}
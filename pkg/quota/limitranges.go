@@ -0,0 +1,211 @@
+package quota
+
+import (
+	"sort"
+	"strings"
+
+	kubequota "github.com/aauren/kube-quota/pkg"
+	"github.com/aauren/kube-quota/pkg/unit"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	HeaderMin            = "Min"
+	HeaderMax            = "Max"
+	HeaderDefaultRequest = "Default Request"
+	HeaderDefaultLimit   = "Default Limit"
+	HeaderMaxRatio       = "Max Limit/Request Ratio"
+)
+
+// LimitRangeDefaults holds the requests and limits that the LimitRanges of a namespace give to containers that don't set their own
+type LimitRangeDefaults struct {
+	Requests v1.ResourceList
+	Limits   v1.ResourceList
+}
+
+// NewLimitRangeDefaults combines the container defaults of every LimitRange. Just like the LimitRanger admission plugin, the first
+// LimitRange that gives a default for a resource wins.
+func NewLimitRangeDefaults(lrs []v1.LimitRange) *LimitRangeDefaults {
+	sorted := append([]v1.LimitRange{}, lrs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	d := LimitRangeDefaults{
+		Requests: v1.ResourceList{},
+		Limits:   v1.ResourceList{},
+	}
+	for idx := range sorted {
+		for _, item := range sorted[idx].Spec.Limits {
+			if item.Type != v1.LimitTypeContainer {
+				continue
+			}
+			item = defaultLimitRangeItem(item)
+			for key, val := range item.DefaultRequest {
+				if _, ok := d.Requests[key]; !ok {
+					d.Requests[key] = val.DeepCopy()
+				}
+			}
+			for key, val := range item.Default {
+				if _, ok := d.Limits[key]; !ok {
+					d.Limits[key] = val.DeepCopy()
+				}
+			}
+		}
+	}
+	return &d
+}
+
+// defaultLimitRangeItem fills in the defaults that the API server gives a container LimitRange item when it is created: the default
+// limit falls back to the max, and the default request falls back to the default limit and then to the min. LimitRanges read from the
+// API server already have these, but manifests may not.
+func defaultLimitRangeItem(item v1.LimitRangeItem) v1.LimitRangeItem {
+	item = *item.DeepCopy()
+	if item.Default == nil {
+		item.Default = v1.ResourceList{}
+	}
+	if item.DefaultRequest == nil {
+		item.DefaultRequest = v1.ResourceList{}
+	}
+	for _, from := range []struct{ src, dst v1.ResourceList }{
+		{src: item.Max, dst: item.Default},
+		{src: item.Default, dst: item.DefaultRequest},
+		{src: item.Min, dst: item.DefaultRequest},
+	} {
+		for key, val := range from.src {
+			if _, ok := from.dst[key]; !ok {
+				from.dst[key] = val.DeepCopy()
+			}
+		}
+	}
+	return item
+}
+
+// Apply returns the pod with the defaults given to every container that doesn't set its own requests or limits. Pods that were read from
+// the API server already went through admission, which is recognized by them having a UID, and are returned as they are. So is every pod
+// when there are no defaults.
+func (d *LimitRangeDefaults) Apply(pod *v1.Pod) *v1.Pod {
+	if d == nil || pod.UID != "" || (len(d.Requests) == 0 && len(d.Limits) == 0) {
+		return pod
+	}
+
+	pod = pod.DeepCopy()
+	for idx := range pod.Spec.InitContainers {
+		d.applyToContainer(&pod.Spec.InitContainers[idx])
+	}
+	for idx := range pod.Spec.Containers {
+		d.applyToContainer(&pod.Spec.Containers[idx])
+	}
+	return pod
+}
+
+func (d *LimitRangeDefaults) applyToContainer(cnt *v1.Container) {
+	res := &cnt.Resources
+	if res.Requests == nil {
+		res.Requests = v1.ResourceList{}
+	}
+	if res.Limits == nil {
+		res.Limits = v1.ResourceList{}
+	}
+
+	// Before admission, the API server gives containers that only set a limit a request of the same amount
+	for key, val := range res.Limits {
+		if _, ok := res.Requests[key]; !ok {
+			res.Requests[key] = val.DeepCopy()
+		}
+	}
+	for key, val := range d.Limits {
+		if _, ok := res.Limits[key]; !ok {
+			res.Limits[key] = val.DeepCopy()
+		}
+	}
+	for key, val := range d.Requests {
+		if _, ok := res.Requests[key]; !ok {
+			res.Requests[key] = val.DeepCopy()
+		}
+	}
+}
+
+// LimitRangeRow is what a single LimitRange item says about a single resource, as it is shown in the limitrange table
+type LimitRangeRow struct {
+	Name     string
+	Type     v1.LimitType
+	Resource v1.ResourceName
+	Item     *v1.LimitRangeItem
+}
+
+// LimitRangeRows returns a row for every resource of every item in the LimitRange, in item order and then sorted by resource
+func LimitRangeRows(lr *v1.LimitRange) []*LimitRangeRow {
+	rows := make([]*LimitRangeRow, 0)
+	for idx := range lr.Spec.Limits {
+		item := &lr.Spec.Limits[idx]
+		resources := make(map[v1.ResourceName]bool)
+		for _, rl := range []v1.ResourceList{item.Min, item.Max, item.Default, item.DefaultRequest, item.MaxLimitRequestRatio} {
+			for key := range rl {
+				resources[key] = true
+			}
+		}
+
+		names := make([]v1.ResourceName, 0, len(resources))
+		for name := range resources {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i] < names[j]
+		})
+		for _, name := range names {
+			rows = append(rows, &LimitRangeRow{Name: lr.Name, Type: item.Type, Resource: name, Item: item})
+		}
+	}
+	return rows
+}
+
+func (r *LimitRangeRow) TableHeader() []string {
+	return []string{HeaderMin, HeaderMax, HeaderDefaultRequest, HeaderDefaultLimit, HeaderMaxRatio}
+}
+
+func (r *LimitRangeRow) ValueForHeader(hdr string) (unit.UnitWriter, error) {
+	var rl v1.ResourceList
+	switch hdr {
+	case HeaderMin:
+		rl = r.Item.Min
+	case HeaderMax:
+		rl = r.Item.Max
+	case HeaderDefaultRequest:
+		rl = r.Item.DefaultRequest
+	case HeaderDefaultLimit:
+		rl = r.Item.Default
+	case HeaderMaxRatio:
+		// Ratios have no unit of their own
+		if val, ok := r.Item.MaxLimitRequestRatio[r.Resource]; ok {
+			return &val, nil
+		}
+		return nil, &NoValueForHeaderError{Header: hdr}
+	default:
+		return nil, &NoValueForHeaderError{Header: hdr}
+	}
+
+	val, ok := rl[r.Resource]
+	if !ok {
+		return nil, &NoValueForHeaderError{Header: hdr}
+	}
+	return quantityValue(r.Resource, val)
+}
+
+// quantityValue returns a quantity of a resource in the unit that it should be shown in
+func quantityValue(name v1.ResourceName, val resource.Quantity) (unit.UnitWriter, error) {
+	//nolint:exhaustive // We don't care to be exhaustive here
+	switch name {
+	case v1.ResourceCPU:
+		return unit.NewUnitWriter(kubequota.CPUMilicore(val.MilliValue()), unit.Cores)
+	case v1.ResourceMemory:
+		return unit.NewUnitWriter(kubequota.MemBytes(val.Value()), unit.Bytes)
+	case v1.ResourceStorage, v1.ResourceEphemeralStorage:
+		return unit.NewUnitWriter(kubequota.StorageBytes(val.Value()), unit.Bytes)
+	}
+	if strings.HasPrefix(string(name), v1.ResourceHugePagesPrefix) {
+		return unit.NewUnitWriter(kubequota.MemBytes(val.Value()), unit.Bytes)
+	}
+	return unit.NewUnitWriter(kubequota.ResourceNum(val.Value()), unit.Count)
+}
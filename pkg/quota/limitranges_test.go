@@ -0,0 +1,177 @@
+package quota
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func limitRange(name string, items ...v1.LimitRangeItem) v1.LimitRange {
+	return v1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: v1.LimitRangeSpec{Limits: items}}
+}
+
+func TestNewLimitRangeDefaults(t *testing.T) {
+	tests := []struct {
+		name         string
+		lrs          []v1.LimitRange
+		wantRequests v1.ResourceList
+		wantLimits   v1.ResourceList
+	}{
+		{
+			name:         "no limit ranges",
+			wantRequests: v1.ResourceList{},
+			wantLimits:   v1.ResourceList{},
+		},
+		{
+			name: "defaults",
+			lrs: []v1.LimitRange{limitRange("lr", v1.LimitRangeItem{
+				Type:           v1.LimitTypeContainer,
+				Default:        resources("cpu", "1", "memory", "1Gi"),
+				DefaultRequest: resources("cpu", "500m", "memory", "512Mi"),
+			})},
+			wantRequests: resources("cpu", "500m", "memory", "512Mi"),
+			wantLimits:   resources("cpu", "1", "memory", "1Gi"),
+		},
+		{
+			name:         "max becomes the default and then the default request",
+			lrs:          []v1.LimitRange{limitRange("lr", v1.LimitRangeItem{Type: v1.LimitTypeContainer, Max: resources("cpu", "2")})},
+			wantRequests: resources("cpu", "2"),
+			wantLimits:   resources("cpu", "2"),
+		},
+		{
+			name: "default becomes the default request",
+			lrs: []v1.LimitRange{limitRange("lr", v1.LimitRangeItem{
+				Type:    v1.LimitTypeContainer,
+				Max:     resources("cpu", "4"),
+				Default: resources("cpu", "1"),
+			})},
+			wantRequests: resources("cpu", "1"),
+			wantLimits:   resources("cpu", "1"),
+		},
+		{
+			name:         "min becomes the default request",
+			lrs:          []v1.LimitRange{limitRange("lr", v1.LimitRangeItem{Type: v1.LimitTypeContainer, Min: resources("memory", "64Mi")})},
+			wantRequests: resources("memory", "64Mi"),
+			wantLimits:   v1.ResourceList{},
+		},
+		{
+			name: "max wins over min for the default request",
+			lrs: []v1.LimitRange{limitRange("lr", v1.LimitRangeItem{
+				Type: v1.LimitTypeContainer,
+				Min:  resources("cpu", "100m"),
+				Max:  resources("cpu", "2"),
+			})},
+			wantRequests: resources("cpu", "2"),
+			wantLimits:   resources("cpu", "2"),
+		},
+		{
+			name: "first limit range by name wins",
+			lrs: []v1.LimitRange{
+				limitRange("b", v1.LimitRangeItem{Type: v1.LimitTypeContainer, Default: resources("cpu", "2", "memory", "2Gi")}),
+				limitRange("a", v1.LimitRangeItem{Type: v1.LimitTypeContainer, Default: resources("cpu", "1")}),
+			},
+			wantRequests: resources("cpu", "1", "memory", "2Gi"),
+			wantLimits:   resources("cpu", "1", "memory", "2Gi"),
+		},
+		{
+			name: "only container items",
+			lrs: []v1.LimitRange{limitRange("lr",
+				v1.LimitRangeItem{Type: v1.LimitTypePod, Max: resources("cpu", "8")},
+				v1.LimitRangeItem{Type: v1.LimitTypePersistentVolumeClaim, Max: resources("storage", "10Gi")},
+			)},
+			wantRequests: v1.ResourceList{},
+			wantLimits:   v1.ResourceList{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewLimitRangeDefaults(tt.lrs)
+			assertResourceList(t, d.Requests, tt.wantRequests)
+			assertResourceList(t, d.Limits, tt.wantLimits)
+		})
+	}
+}
+
+func TestLimitRangeDefaultsApply(t *testing.T) {
+	d := NewLimitRangeDefaults([]v1.LimitRange{limitRange("lr", v1.LimitRangeItem{
+		Type:           v1.LimitTypeContainer,
+		Default:        resources("cpu", "1", "memory", "1Gi"),
+		DefaultRequest: resources("cpu", "250m", "memory", "256Mi"),
+	})})
+
+	tests := []struct {
+		name         string
+		cnt          v1.Container
+		wantRequests v1.ResourceList
+		wantLimits   v1.ResourceList
+	}{
+		{
+			name:         "nothing set",
+			cnt:          container("app", nil, nil),
+			wantRequests: resources("cpu", "250m", "memory", "256Mi"),
+			wantLimits:   resources("cpu", "1", "memory", "1Gi"),
+		},
+		{
+			name:         "requests set",
+			cnt:          container("app", resources("cpu", "100m", "memory", "2Gi"), nil),
+			wantRequests: resources("cpu", "100m", "memory", "2Gi"),
+			wantLimits:   resources("cpu", "1", "memory", "1Gi"),
+		},
+		{
+			name:         "limits are copied to the requests before the defaults",
+			cnt:          container("app", nil, resources("cpu", "2")),
+			wantRequests: resources("cpu", "2", "memory", "256Mi"),
+			wantLimits:   resources("cpu", "2", "memory", "1Gi"),
+		},
+		{
+			name:         "everything set",
+			cnt:          container("app", resources("cpu", "3", "memory", "3Gi"), resources("cpu", "4", "memory", "4Gi")),
+			wantRequests: resources("cpu", "3", "memory", "3Gi"),
+			wantLimits:   resources("cpu", "4", "memory", "4Gi"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{Spec: v1.PodSpec{InitContainers: []v1.Container{tt.cnt}, Containers: []v1.Container{tt.cnt}}}
+			orig := pod.DeepCopy()
+			got := d.Apply(pod)
+			for _, cnt := range []v1.Container{got.Spec.InitContainers[0], got.Spec.Containers[0]} {
+				assertResourceList(t, cnt.Resources.Requests, tt.wantRequests)
+				assertResourceList(t, cnt.Resources.Limits, tt.wantLimits)
+			}
+			assertResourceList(t, pod.Spec.Containers[0].Resources.Requests, orig.Spec.Containers[0].Resources.Requests)
+			assertResourceList(t, pod.Spec.Containers[0].Resources.Limits, orig.Spec.Containers[0].Resources.Limits)
+		})
+	}
+}
+
+func TestLimitRangeDefaultsApplyUnchanged(t *testing.T) {
+	d := NewLimitRangeDefaults([]v1.LimitRange{limitRange("lr", v1.LimitRangeItem{
+		Type:    v1.LimitTypeContainer,
+		Default: resources("cpu", "1"),
+	})})
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{container("app", nil, nil)}}}
+	admitted := pod.DeepCopy()
+	admitted.UID = "0b0c2c4e-7a43-4e36-9d8c-3f4f0b6b1a2d"
+
+	tests := []struct {
+		name string
+		d    *LimitRangeDefaults
+		pod  *v1.Pod
+	}{
+		{name: "nil defaults", d: nil, pod: pod},
+		{name: "no defaults", d: NewLimitRangeDefaults(nil), pod: pod},
+		{name: "admitted pod", d: d, pod: admitted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.Apply(tt.pod); got != tt.pod {
+				t.Errorf("Apply() = %v, want the pod unchanged", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package quota
+
+import (
+	"sort"
+	"strings"
+
+	kubequota "github.com/aauren/kube-quota/pkg"
+	"github.com/aauren/kube-quota/pkg/unit"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// legacyObjectCounts holds the object counts that quotas accepted before count/<resource>.<group> existed, they can be given either bare
+// or with the count/ prefix
+var legacyObjectCounts = map[v1.ResourceName]bool{
+	v1.ResourcePods:                   true,
+	v1.ResourceServices:               true,
+	v1.ResourceSecrets:                true,
+	v1.ResourceConfigMaps:             true,
+	v1.ResourceReplicationControllers: true,
+	v1.ResourceQuotas:                 true,
+	v1.ResourceServicesLoadBalancers:  true,
+	v1.ResourceServicesNodePorts:      true,
+}
+
+// objectCountName returns the name of the object count that a quota key limits, which is the key without its count/ prefix. ok is false
+// for keys that aren't object counts. PersistentVolumeClaims are counted along with their storage, so they aren't object counts here.
+func objectCountName(key v1.ResourceName) (name v1.ResourceName, ok bool) {
+	if n, found := strings.CutPrefix(string(key), objectCountPrefix); found {
+		if v1.ResourceName(n) == v1.ResourcePersistentVolumeClaims {
+			return "", false
+		}
+		return v1.ResourceName(n), true
+	}
+	return key, legacyObjectCounts[key]
+}
+
+// ObjectCountResource returns the API resource whose objects are counted for an object count
+func ObjectCountResource(name v1.ResourceName) schema.GroupResource {
+	if legacyObjectCounts[name] {
+		if IsServiceCount(name) {
+			return schema.GroupResource{Resource: string(v1.ResourceServices)}
+		}
+		return schema.GroupResource{Resource: string(name)}
+	}
+	// Everything else is given as <resource>.<group>, where the group may contain dots of its own
+	return schema.ParseGroupResource(string(name))
+}
+
+// IsServiceCount returns true for the object counts that are worked out from the namespace's services rather than by simply counting
+// objects
+func IsServiceCount(name v1.ResourceName) bool {
+	//nolint:exhaustive // We don't care to be exhaustive here
+	switch name {
+	case v1.ResourceServices, v1.ResourceServicesLoadBalancers, v1.ResourceServicesNodePorts:
+		return true
+	}
+	return false
+}
+
+// ServiceCounts returns the services, load balancers and node ports that the services of a namespace are charged for, following the same
+// rules as quota admission
+func ServiceCounts(svcs []v1.Service) map[v1.ResourceName]int64 {
+	counts := map[v1.ResourceName]int64{
+		v1.ResourceServices:              0,
+		v1.ResourceServicesLoadBalancers: 0,
+		v1.ResourceServicesNodePorts:     0,
+	}
+	for idx := range svcs {
+		svc := &svcs[idx]
+		counts[v1.ResourceServices]++
+		//nolint:exhaustive // Other service types don't use load balancers or node ports
+		switch svc.Spec.Type {
+		case v1.ServiceTypeLoadBalancer:
+			counts[v1.ResourceServicesLoadBalancers]++
+			counts[v1.ResourceServicesNodePorts] += nodePorts(svc)
+		case v1.ServiceTypeNodePort:
+			counts[v1.ResourceServicesNodePorts] += nodePorts(svc)
+		}
+	}
+	return counts
+}
+
+// nodePorts returns the number of node ports that a service is allocated. Every port gets one unless a load balancer was asked not to
+// allocate them, in which case only the ports that set one explicitly count.
+func nodePorts(svc *v1.Service) int64 {
+	if svc.Spec.Type == v1.ServiceTypeNodePort || svc.Spec.AllocateLoadBalancerNodePorts == nil || *svc.Spec.AllocateLoadBalancerNodePorts {
+		return int64(len(svc.Spec.Ports))
+	}
+
+	var count int64
+	for _, port := range svc.Spec.Ports {
+		if port.NodePort != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ObjectCountNames returns the object counts that any of the quotas limit, sorted by name
+func ObjectCountNames(kqs ...*KubeQuota) []v1.ResourceName {
+	seen := make(map[v1.ResourceName]bool)
+	names := make([]v1.ResourceName, 0)
+	for _, kq := range kqs {
+		for name := range kq.WQ.Objects {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// SetObjectCount sets the number of objects that the namespace holds for an object count, replacing any count that was set before.
+// Pods are counted as they are added, so their count never needs to be set.
+func (t *NamespaceWorkloadQuota) SetObjectCount(name v1.ResourceName, count int64) {
+	t.objects[name] = count
+}
+
+func (w *WorkloadQuota) setObjectCount(name v1.ResourceName, count int64) {
+	if w.Objects == nil {
+		w.Objects = make(map[v1.ResourceName]int64)
+	}
+	// Quotas can limit the same count twice, with and without the count/ prefix, in which case the lower limit wins
+	if cur, ok := w.Objects[name]; ok && cur < count {
+		return
+	}
+	w.Objects[name] = count
+}
+
+// ObjectCountHeader returns the header of the column for an object count
+func ObjectCountHeader(name v1.ResourceName) string {
+	return string(name) + " " + HeaderCountSuffix
+}
+
+// parseObjectCountHeader returns the object count that a header is for, ok is false for headers that aren't for an object count
+func parseObjectCountHeader(hdr string) (name v1.ResourceName, ok bool) {
+	n, found := strings.CutSuffix(hdr, " "+HeaderCountSuffix)
+	if !found {
+		return "", false
+	}
+	_, ok = objectCountName(v1.ResourceName(objectCountPrefix + n))
+	return v1.ResourceName(n), ok
+}
+
+// objectHeaders returns the headers for every object count that the workload holds, in a stable order
+func (w *WorkloadQuota) objectHeaders() []string {
+	header := make([]string, 0, len(w.Objects))
+	for name := range w.Objects {
+		header = append(header, ObjectCountHeader(name))
+	}
+	sort.Strings(header)
+	return header
+}
+
+// objectValue returns the count for an object count header. Objects that weren't counted, for instance because the API server doesn't
+// serve them or because the workload is a single pod, have nothing to show for it.
+func (w *WorkloadQuota) objectValue(name v1.ResourceName) (unit.UnitWriter, error) {
+	count, ok := w.Objects[name]
+	if !ok {
+		return nil, &NoValueForHeaderError{Header: ObjectCountHeader(name)}
+	}
+	return unit.NewUnitWriter(kubequota.ResourceNum(count), unit.Count)
+}
+
+// hasObjectCount returns true if the workload holds the object count that the header is for
+func (w *WorkloadQuota) hasObjectCount(hdr string) bool {
+	name, _ := parseObjectCountHeader(hdr)
+	_, ok := w.Objects[name]
+	return ok
+}
@@ -0,0 +1,192 @@
+package quota
+
+import (
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestObjectCountName(t *testing.T) {
+	tests := []struct {
+		key      v1.ResourceName
+		wantName v1.ResourceName
+		wantOK   bool
+	}{
+		{key: "count/deployments.apps", wantName: "deployments.apps", wantOK: true},
+		{key: "count/widgets.stable.example.com", wantName: "widgets.stable.example.com", wantOK: true},
+		{key: "count/configmaps", wantName: "configmaps", wantOK: true},
+		{key: "count/services", wantName: "services", wantOK: true},
+		// The legacy names are object counts without the count/ prefix as well
+		{key: "pods", wantName: "pods", wantOK: true},
+		{key: "services", wantName: "services", wantOK: true},
+		{key: "secrets", wantName: "secrets", wantOK: true},
+		{key: "configmaps", wantName: "configmaps", wantOK: true},
+		{key: "replicationcontrollers", wantName: "replicationcontrollers", wantOK: true},
+		{key: "resourcequotas", wantName: "resourcequotas", wantOK: true},
+		{key: "services.loadbalancers", wantName: "services.loadbalancers", wantOK: true},
+		{key: "services.nodeports", wantName: "services.nodeports", wantOK: true},
+		// Claims are counted along with their storage
+		{key: "count/persistentvolumeclaims"},
+		{key: "persistentvolumeclaims"},
+		{key: "requests.cpu"},
+		{key: "deployments.apps"},
+		{key: "requests.nvidia.com/gpu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.key), func(t *testing.T) {
+			name, ok := objectCountName(tt.key)
+			if ok != tt.wantOK || (ok && name != tt.wantName) {
+				t.Errorf("objectCountName() = %q, %v, want %q, %v", name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestObjectCountResource(t *testing.T) {
+	tests := []struct {
+		name v1.ResourceName
+		want schema.GroupResource
+	}{
+		{name: "deployments.apps", want: schema.GroupResource{Group: "apps", Resource: "deployments"}},
+		{name: "widgets.stable.example.com", want: schema.GroupResource{Group: "stable.example.com", Resource: "widgets"}},
+		{name: "jobs.batch", want: schema.GroupResource{Group: "batch", Resource: "jobs"}},
+		{name: "configmaps", want: schema.GroupResource{Resource: "configmaps"}},
+		{name: "pods", want: schema.GroupResource{Resource: "pods"}},
+		{name: "secrets", want: schema.GroupResource{Resource: "secrets"}},
+		{name: "services", want: schema.GroupResource{Resource: "services"}},
+		{name: "services.loadbalancers", want: schema.GroupResource{Resource: "services"}},
+		{name: "services.nodeports", want: schema.GroupResource{Resource: "services"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			if got := ObjectCountResource(tt.name); got != tt.want {
+				t.Errorf("ObjectCountResource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceCounts(t *testing.T) {
+	noAllocate := false
+	ports := func(nodePorts ...int32) []v1.ServicePort {
+		sps := make([]v1.ServicePort, 0, len(nodePorts))
+		for _, np := range nodePorts {
+			sps = append(sps, v1.ServicePort{NodePort: np})
+		}
+		return sps
+	}
+	svc := func(typ v1.ServiceType, allocate *bool, sps []v1.ServicePort) v1.Service {
+		return v1.Service{Spec: v1.ServiceSpec{Type: typ, AllocateLoadBalancerNodePorts: allocate, Ports: sps}}
+	}
+
+	tests := []struct {
+		name          string
+		svcs          []v1.Service
+		want          int64
+		loadBalancers int64
+		nodePorts     int64
+	}{
+		{name: "no services"},
+		{name: "cluster ip", svcs: []v1.Service{svc(v1.ServiceTypeClusterIP, nil, ports(0, 0))}, want: 1},
+		{name: "external name", svcs: []v1.Service{svc(v1.ServiceTypeExternalName, nil, nil)}, want: 1},
+		{name: "node port", svcs: []v1.Service{svc(v1.ServiceTypeNodePort, nil, ports(30000, 0))}, want: 1, nodePorts: 2},
+		{name: "node port that doesn't allocate node ports", svcs: []v1.Service{svc(v1.ServiceTypeNodePort, &noAllocate, ports(0, 0))},
+			want: 1, nodePorts: 2},
+		{name: "load balancer", svcs: []v1.Service{svc(v1.ServiceTypeLoadBalancer, nil, ports(0, 0, 0))}, want: 1, loadBalancers: 1,
+			nodePorts: 3},
+		{name: "load balancer without node ports", svcs: []v1.Service{svc(v1.ServiceTypeLoadBalancer, &noAllocate, ports(0, 0))},
+			want: 1, loadBalancers: 1},
+		{name: "load balancer with explicit node ports", svcs: []v1.Service{svc(v1.ServiceTypeLoadBalancer, &noAllocate,
+			ports(30000, 0, 30001))}, want: 1, loadBalancers: 1, nodePorts: 2},
+		{name: "mixed", svcs: []v1.Service{
+			svc(v1.ServiceTypeClusterIP, nil, ports(0)),
+			svc(v1.ServiceTypeNodePort, nil, ports(0)),
+			svc(v1.ServiceTypeLoadBalancer, nil, ports(0, 0)),
+		}, want: 3, loadBalancers: 1, nodePorts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ServiceCounts(tt.svcs)
+			want := map[v1.ResourceName]int64{
+				v1.ResourceServices:              tt.want,
+				v1.ResourceServicesLoadBalancers: tt.loadBalancers,
+				v1.ResourceServicesNodePorts:     tt.nodePorts,
+			}
+			if len(got) != len(want) {
+				t.Fatalf("ServiceCounts() = %v, want %v", got, want)
+			}
+			for name, count := range want {
+				if got[name] != count {
+					t.Errorf("ServiceCounts() = %v, want %v", got, want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestObjectCountsFromQuota(t *testing.T) {
+	kq := ForKubeQuota(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "q"},
+		Spec: v1.ResourceQuotaSpec{Hard: resources(
+			"requests.cpu", "1",
+			"pods", "10",
+			"secrets", "20",
+			"count/secrets", "5",
+			"count/deployments.apps", "4",
+			"count/persistentvolumeclaims", "3",
+		)},
+	})
+	other := ForKubeQuota(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec:       v1.ResourceQuotaSpec{Hard: resources("configmaps", "3", "count/deployments.apps", "2")},
+	})
+
+	// The lower of the limits with and without the count/ prefix wins
+	if got := kq.WQ.Objects[v1.ResourceSecrets]; got != 5 {
+		t.Errorf("secrets limit = %d, want 5", got)
+	}
+	if _, ok := kq.WQ.Objects[v1.ResourcePersistentVolumeClaims]; ok {
+		t.Error("persistentvolumeclaims counted as an object count, want it counted with the storage")
+	}
+	want := []v1.ResourceName{"configmaps", "deployments.apps", "pods", "secrets"}
+	if got := ObjectCountNames(kq, other); !slices.Equal(got, want) {
+		t.Errorf("ObjectCountNames() = %v, want %v", got, want)
+	}
+}
+
+func TestParseObjectCountHeader(t *testing.T) {
+	tests := []struct {
+		hdr      string
+		wantName v1.ResourceName
+		wantOK   bool
+	}{
+		{hdr: "deployments.apps Count", wantName: "deployments.apps", wantOK: true},
+		{hdr: "widgets.example.com Count", wantName: "widgets.example.com", wantOK: true},
+		{hdr: "configmaps Count", wantName: "configmaps", wantOK: true},
+		{hdr: "pods Count", wantName: "pods", wantOK: true},
+		{hdr: "secrets Count", wantName: "secrets", wantOK: true},
+		{hdr: "services.loadbalancers Count", wantName: "services.loadbalancers", wantOK: true},
+		// Claims are counted along with their storage, under the PVCs header
+		{hdr: "persistentvolumeclaims Count", wantName: "persistentvolumeclaims"},
+		{hdr: HeaderPVCs},
+		{hdr: HeaderCPUReq},
+		{hdr: "deployments.apps"},
+		{hdr: "nvidia.com/gpu Request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hdr, func(t *testing.T) {
+			name, ok := parseObjectCountHeader(tt.hdr)
+			if ok != tt.wantOK || (ok && name != tt.wantName) {
+				t.Errorf("parseObjectCountHeader() = %q, %v, want %q, %v", name, ok, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package quota
 
 import (
+	"time"
+
 	v1 "k8s.io/api/core/v1"
 )
 
+// QuotaForPod returns the quota that a pod reserves, following the same rules as the scheduler and quota admission. Every app container
+// and native sidecar gets its own WorkloadQuota. When init containers need more than the long running containers, the difference is
+// added as a WorkloadQuota named InitContainersName, and any overhead from the pod's RuntimeClass is added as one named PodOverheadName.
+// This way the sum of the pod's WorkloadQuotas is always its effective request and limit.
 func QuotaForPod(pod *v1.Pod) *PodQuota {
 	podQuota := PodQuota{
 		Name:           pod.Name,
@@ -11,29 +17,125 @@ func QuotaForPod(pod *v1.Pod) *PodQuota {
 		WorkloadQuotas: make([]*WorkloadQuota, 0),
 	}
 
-	for _, cnt := range pod.Spec.Containers {
-		tmpQuota := WorkloadQuota{
-			StorageQuota: &StorageQuota{
-				Ephemeral:      &EphemeralQuota{},
-				StorageClasses: make(map[string]*StorageClassQuota),
-			},
+	for idx := range pod.Spec.Containers {
+		podQuota.WorkloadQuotas = append(podQuota.WorkloadQuotas, containerQuota(&pod.Spec.Containers[idx]))
+	}
+	for idx := range pod.Spec.InitContainers {
+		if isSidecar(&pod.Spec.InitContainers[idx]) {
+			podQuota.WorkloadQuotas = append(podQuota.WorkloadQuotas, containerQuota(&pod.Spec.InitContainers[idx]))
 		}
-		tmpQuota.Name = cnt.Name
-		tmpQuota.Limit = ConvertK8sResourceList(cnt.Resources.Limits)
-		tmpQuota.Request = ConvertK8sResourceList(cnt.Resources.Requests)
-		podQuota.WorkloadQuotas = append(podQuota.WorkloadQuotas, &tmpQuota)
+	}
+
+	initRequests := initContainerExtra(pod, func(r v1.ResourceRequirements) v1.ResourceList { return r.Requests })
+	initLimits := initContainerExtra(pod, func(r v1.ResourceRequirements) v1.ResourceList { return r.Limits })
+	if len(initRequests) > 0 || len(initLimits) > 0 {
+		podQuota.WorkloadQuotas = append(podQuota.WorkloadQuotas, resourceListQuota(InitContainersName, initRequests, initLimits))
+	}
+
+	if len(pod.Spec.Overhead) > 0 {
+		podQuota.WorkloadQuotas = append(podQuota.WorkloadQuotas, resourceListQuota(PodOverheadName, pod.Spec.Overhead,
+			overheadLimits(pod)))
 	}
 
 	return &podQuota
 }
 
-func QuotaForPodList(pl *v1.PodList) *NamespaceWorkloadQuota {
-	tq := NamespaceWorkloadQuota{
+func containerQuota(cnt *v1.Container) *WorkloadQuota {
+	return resourceListQuota(cnt.Name, cnt.Resources.Requests, cnt.Resources.Limits)
+}
+
+// resourceListQuota converts requests and limits into a WorkloadQuota. Just like quota admission, only the ephemeral-storage that
+// containers request counts towards ephemeral storage, emptyDir sizeLimits are only enforced by the kubelet through eviction and don't
+// reserve anything.
+func resourceListQuota(name string, requests, limits v1.ResourceList) *WorkloadQuota {
+	return &WorkloadQuota{
+		Name:    name,
+		Request: ConvertK8sResourceList(requests),
+		Limit:   ConvertK8sResourceList(limits),
+		StorageQuota: &StorageQuota{
+			Ephemeral:      ConvertK8sResourceListToEphemeral(requests, limits),
+			StorageClasses: make(map[string]*StorageClassQuota),
+		},
+	}
+}
+
+// NewNamespaceWorkloadQuota creates an empty NamespaceWorkloadQuota, keepPods controls whether the individual PodQuotas are kept once
+// they have been added to the total
+func NewNamespaceWorkloadQuota(ns string, keepPods bool) *NamespaceWorkloadQuota {
+	return &NamespaceWorkloadQuota{
+		Namespace: ns,
 		PodQuotas: make([]*PodQuota, 0),
+		keepPods:  keepPods,
+		total:     newEmptyWorkloadQuota(),
+		podIndex:  make(map[string]int),
+		claims:    make(map[string]*WorkloadQuota),
+		objects:   make(map[v1.ResourceName]int64),
+	}
+}
+
+func objectKey(ns, name string) string {
+	return ns + "/" + name
+}
+
+// AddPod converts the pod into a PodQuota and adds it to the namespace
+func (t *NamespaceWorkloadQuota) AddPod(pod *v1.Pod) {
+	t.AddPodQuota(QuotaForPod(pod))
+}
+
+// AddPodQuota adds the PodQuota to the namespace total, and keeps it if the namespace was asked to keep its pods
+func (t *NamespaceWorkloadQuota) AddPodQuota(pq *PodQuota) {
+	t.total.Add(pq.Sum())
+	if t.keepPods {
+		t.podIndex[objectKey(pq.Namespace, pq.Name)] = len(t.PodQuotas)
+		t.PodQuotas = append(t.PodQuotas, pq)
+	}
+}
+
+// UpdatePod adds the pod to the namespace, replacing any version of the same pod that was added before. Replacing pods only works for
+// namespaces that keep their pods.
+func (t *NamespaceWorkloadQuota) UpdatePod(pod *v1.Pod) {
+	pq := QuotaForPod(pod)
+	idx, ok := t.podIndex[objectKey(pq.Namespace, pq.Name)]
+	if !ok {
+		t.AddPodQuota(pq)
+		return
+	}
+
+	t.total.Sub(t.PodQuotas[idx].Sum())
+	t.total.Add(pq.Sum())
+	t.PodQuotas[idx] = pq
+}
+
+// DeletePod removes a pod that was previously added from the namespace. Deleting pods only works for namespaces that keep their pods.
+func (t *NamespaceWorkloadQuota) DeletePod(ns, name string) {
+	key := objectKey(ns, name)
+	idx, ok := t.podIndex[key]
+	if !ok {
+		return
 	}
+
+	t.total.Sub(t.PodQuotas[idx].Sum())
+
+	// Move the last pod into the position of the deleted one so that we don't have to shift every pod after it
+	last := len(t.PodQuotas) - 1
+	t.PodQuotas[idx] = t.PodQuotas[last]
+	t.podIndex[objectKey(t.PodQuotas[idx].Namespace, t.PodQuotas[idx].Name)] = idx
+	t.PodQuotas = t.PodQuotas[:last]
+	delete(t.podIndex, key)
+}
+
+// QuotaForPodList adds up every pod in the list that is still charged against quota. Pods that IsTerminalPod reports as terminal (those
+// that have Succeeded or Failed, or are past their deletion grace period) are dropped, callers that want them counted should add them
+// with AddPod instead.
+func QuotaForPodList(pl *v1.PodList) *NamespaceWorkloadQuota {
+	tq := NewNamespaceWorkloadQuota("", true)
+	now := time.Now()
 	for idx := range pl.Items {
-		tq.PodQuotas = append(tq.PodQuotas, QuotaForPod(&pl.Items[idx]))
+		if IsTerminalPod(&pl.Items[idx], now) {
+			continue
+		}
+		tq.AddPod(&pl.Items[idx])
 	}
 
-	return &tq
+	return tq
 }
@@ -10,29 +10,49 @@ import (
 
 const (
 	storageClassSuffix = ".storageclass.storage.k8s.io/"
+	objectCountPrefix  = "count/"
+	requestsPrefix     = "requests."
+	limitsPrefix       = "limits."
 )
 
-func asInt64(quantity resource.Quantity) int64 {
-	var by []byte
-	_, suffix := quantity.CanonicalizeBytes(by)
-	if string(suffix) == "" {
-		return quantity.MilliValue()
-	}
-	// klog.Infof("Suffix was: %s", suffix)
-	return quantity.Value()
-}
-
 func ConvertK8sResourceList(rl v1.ResourceList) *ComputeQuota {
 	cQuota := ComputeQuota{}
 
 	cpu := rl[v1.ResourceCPU]
-	cQuota.CPU = kubequota.CPUMilicore(asInt64(cpu))
+	cQuota.CPU = kubequota.CPUMilicore(cpu.MilliValue())
 	mem := rl[v1.ResourceMemory]
 	cQuota.Mem = kubequota.MemBytes(mem.Value())
+	for name, val := range rl {
+		if isExtendedResource(name) {
+			cQuota.setExtended(name, val)
+		}
+	}
 
 	return &cQuota
 }
 
+// isExtendedResource returns true for the resources that ComputeQuota keeps in Extended: hugepages and the extended resources that
+// device plugins advertise (such as nvidia.com/gpu). Those are the only compute resources outside of cpu and memory that pods can
+// request, and extended resources are always domain qualified, which sets them apart from object counts and storage.
+func isExtendedResource(name v1.ResourceName) bool {
+	n := string(name)
+	if strings.HasPrefix(n, v1.ResourceHugePagesPrefix) {
+		return true
+	}
+	return strings.Contains(n, "/") && !strings.HasPrefix(n, objectCountPrefix) && !strings.Contains(n, storageClassSuffix) &&
+		!strings.HasPrefix(n, requestsPrefix) && !strings.HasPrefix(n, limitsPrefix)
+}
+
+// ConvertK8sResourceListToEphemeral returns the ephemeral storage out of a pod or container's requests and limits
+func ConvertK8sResourceListToEphemeral(requests, limits v1.ResourceList) *EphemeralQuota {
+	req := requests[v1.ResourceEphemeralStorage]
+	lim := limits[v1.ResourceEphemeralStorage]
+	return &EphemeralQuota{
+		Requests: kubequota.StorageBytes(req.Value()),
+		Limits:   kubequota.StorageBytes(lim.Value()),
+	}
+}
+
 func ConvertK8sHardToWorkload(rl v1.ResourceList) *WorkloadQuota {
 	wq := WorkloadQuota{
 		Limit:   &ComputeQuota{},
@@ -42,13 +62,29 @@ func ConvertK8sHardToWorkload(rl v1.ResourceList) *WorkloadQuota {
 		//nolint:exhaustive // We don't care to be exhaustive here
 		switch key {
 		case v1.ResourceRequestsCPU, v1.ResourceCPU:
-			wq.Request.CPU = kubequota.CPUMilicore(asInt64(val))
+			wq.Request.CPU = kubequota.CPUMilicore(val.MilliValue())
 		case v1.ResourceRequestsMemory, v1.ResourceMemory:
 			wq.Request.Mem = kubequota.MemBytes(val.Value())
 		case v1.ResourceLimitsCPU:
-			wq.Limit.CPU = kubequota.CPUMilicore(asInt64(val))
+			wq.Limit.CPU = kubequota.CPUMilicore(val.MilliValue())
 		case v1.ResourceLimitsMemory:
 			wq.Limit.Mem = kubequota.MemBytes(val.Value())
+		default:
+			// Extended resources and hugepages can be given with a requests. or limits. prefix, hugepages can also be given bare in
+			// which case they limit requests
+			req, isReq := strings.CutPrefix(string(key), requestsPrefix)
+			lim, isLim := strings.CutPrefix(string(key), limitsPrefix)
+			switch {
+			case isReq && isExtendedResource(v1.ResourceName(req)):
+				wq.Request.setExtended(v1.ResourceName(req), val)
+			case isLim && isExtendedResource(v1.ResourceName(lim)):
+				wq.Limit.setExtended(v1.ResourceName(lim), val)
+			case isExtendedResource(key):
+				wq.Request.setExtended(key, val)
+			}
+			if name, ok := objectCountName(key); ok {
+				wq.setObjectCount(name, val.Value())
+			}
 		}
 	}
 
@@ -56,8 +92,14 @@ func ConvertK8sHardToWorkload(rl v1.ResourceList) *WorkloadQuota {
 }
 
 func ConvertK8sHardToStorage(rl v1.ResourceList) *StorageQuota {
-	sq := StorageQuota{}
+	sq := StorageQuota{
+		StorageClasses: make(map[string]*StorageClassQuota),
+	}
 	for key, val := range rl {
+		// PersistentVolumeClaims can also be limited as an object count, which is the same as limiting them bare
+		if key == objectCountPrefix+v1.ResourcePersistentVolumeClaims {
+			key = v1.ResourcePersistentVolumeClaims
+		}
 		//nolint:exhaustive // We don't care to be exhaustive here
 		switch key {
 		case v1.ResourceRequestsEphemeralStorage, v1.ResourceEphemeralStorage:
@@ -72,14 +114,36 @@ func ConvertK8sHardToStorage(rl v1.ResourceList) *StorageQuota {
 			}
 			sq.Ephemeral.Limits = kubequota.StorageBytes(val.Value())
 			continue
+		case v1.ResourceRequestsStorage, v1.ResourcePersistentVolumeClaims:
+			if sq.AllClasses == nil {
+				sq.AllClasses = &StorageClassQuota{}
+			}
+			setStorageClassQuota(sq.AllClasses, key, val)
+			continue
 		}
 
-		//nolint:staticcheck // It's ok for this to be empty for now
-		if strings.Contains(string(key), storageClassSuffix) {
-			// To be implemented at some point in the future
-			// sq.AddNewClass(key, val)
+		// Per storage class keys look like <class>.storageclass.storage.k8s.io/requests.storage
+		class, resource, found := strings.Cut(string(key), storageClassSuffix)
+		if !found {
+			continue
+		}
+		sc, ok := sq.StorageClasses[class]
+		if !ok {
+			sc = &StorageClassQuota{Name: class}
+			sq.StorageClasses[class] = sc
 		}
+		setStorageClassQuota(sc, v1.ResourceName(resource), val)
 	}
 
 	return &sq
 }
+
+func setStorageClassQuota(sc *StorageClassQuota, key v1.ResourceName, val resource.Quantity) {
+	//nolint:exhaustive // We don't care to be exhaustive here
+	switch key {
+	case v1.ResourceRequestsStorage:
+		sc.Requests = kubequota.StorageBytes(val.Value())
+	case v1.ResourcePersistentVolumeClaims:
+		sc.Claims = kubequota.ClaimsNum(val.Value())
+	}
+}
@@ -0,0 +1,33 @@
+package quota
+
+import (
+	"testing"
+
+	kubequota "github.com/aauren/kube-quota/pkg"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestConvertK8sResourceListCPU(t *testing.T) {
+	tests := []struct {
+		cpu  string
+		want kubequota.CPUMilicore
+	}{
+		{cpu: "2", want: 2000},
+		{cpu: "1.5", want: 1500},
+		{cpu: "500m", want: 500},
+		{cpu: "1m", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cpu, func(t *testing.T) {
+			cq := ConvertK8sResourceList(resources("cpu", tt.cpu))
+			if cq.CPU != tt.want {
+				t.Errorf("CPU = %d, want %d", cq.CPU, tt.want)
+			}
+			wq := ConvertK8sHardToWorkload(resources(string(v1.ResourceRequestsCPU), tt.cpu, string(v1.ResourceLimitsCPU), tt.cpu))
+			if wq.Request.CPU != tt.want || wq.Limit.CPU != tt.want {
+				t.Errorf("Request.CPU = %d, Limit.CPU = %d, want %d", wq.Request.CPU, wq.Limit.CPU, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,67 @@
 package quota
 
-import v1 "k8s.io/api/core/v1"
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// EffectiveQuotaName is the name of the quota returned by EffectiveKubeQuota
+const EffectiveQuotaName = "effective"
 
 func ForKubeQuota(kubeq *v1.ResourceQuota) *KubeQuota {
-	kq := KubeQuota{}
-	kq.WQ = ConvertK8sHardToWorkload(kubeq.Spec.Hard)
-	kq.SQ = ConvertK8sHardToStorage(kubeq.Spec.Hard)
+	kq := newKubeQuota(kubeq.Name, normalizeHard(kubeq.Spec.Hard))
+	kq.Scopes = quotaScopes(kubeq)
+	if kubeq.Status.Used != nil {
+		kq.Used = normalizeHard(kubeq.Status.Used)
+	}
+	if kubeq.Status.Hard != nil {
+		kq.StatusHard = normalizeHard(kubeq.Status.Hard)
+	}
+	return kq
+}
+
+func newKubeQuota(name string, hard v1.ResourceList) *KubeQuota {
+	kq := KubeQuota{Name: name, Hard: hard}
+	kq.WQ = ConvertK8sHardToWorkload(hard)
+	kq.SQ = ConvertK8sHardToStorage(hard)
 	return &kq
 }
+
+// normalizeHard returns a copy of a quota's hard limits (or its usage) where resources that can be given either bare or with a requests.
+// prefix (cpu, memory, ephemeral-storage and hugepages) always have the prefix, so that limits on the same resource can be compared
+// across quotas
+func normalizeHard(hard v1.ResourceList) v1.ResourceList {
+	normalized := make(v1.ResourceList, len(hard))
+	for key, val := range hard {
+		//nolint:exhaustive // We don't care to be exhaustive here
+		switch key {
+		case v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage:
+			key = v1.ResourceName(requestsPrefix + string(key))
+		default:
+			if strings.HasPrefix(string(key), v1.ResourceHugePagesPrefix) {
+				key = v1.ResourceName(requestsPrefix + string(key))
+			}
+		}
+		normalized[key] = val.DeepCopy()
+	}
+	return normalized
+}
+
+// EffectiveKubeQuota returns a quota that holds the tightest limit that any of the passed quotas puts on each resource. Admission has to
+// pass every quota in a namespace, so this is what actually limits the namespace. Quotas with scopes only limit some of the pods in the
+// namespace, so they are left out.
+func EffectiveKubeQuota(kqs ...*KubeQuota) *KubeQuota {
+	hard := v1.ResourceList{}
+	for _, kq := range kqs {
+		if kq.Scoped() {
+			continue
+		}
+		for key, val := range kq.Hard {
+			if cur, ok := hard[key]; !ok || val.Cmp(cur) < 0 {
+				hard[key] = val.DeepCopy()
+			}
+		}
+	}
+	return newKubeQuota(EffectiveQuotaName, hard)
+}
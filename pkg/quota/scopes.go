@@ -0,0 +1,173 @@
+package quota
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// quotaScopes returns every scope of a quota as a selector requirement, plain scopes are the same as requirements with the Exists
+// operator
+func quotaScopes(rq *v1.ResourceQuota) []v1.ScopedResourceSelectorRequirement {
+	scopes := make([]v1.ScopedResourceSelectorRequirement, 0, len(rq.Spec.Scopes))
+	for _, scope := range rq.Spec.Scopes {
+		scopes = append(scopes, v1.ScopedResourceSelectorRequirement{ScopeName: scope, Operator: v1.ScopeSelectorOpExists})
+	}
+	if rq.Spec.ScopeSelector != nil {
+		scopes = append(scopes, rq.Spec.ScopeSelector.MatchExpressions...)
+	}
+	return scopes
+}
+
+// Scoped returns true if the quota only charges for the pods that match its scopes
+func (k *KubeQuota) Scoped() bool {
+	return len(k.Scopes) > 0
+}
+
+// ScopeString describes the quota's scopes, for instance "NotBestEffort, PriorityClass in (high, critical)"
+func (k *KubeQuota) ScopeString() string {
+	scopes := make([]string, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		switch scope.Operator {
+		case v1.ScopeSelectorOpExists:
+			scopes = append(scopes, string(scope.ScopeName))
+		case v1.ScopeSelectorOpDoesNotExist:
+			scopes = append(scopes, "!"+string(scope.ScopeName))
+		default:
+			scopes = append(scopes, fmt.Sprintf("%s %s (%s)", scope.ScopeName, strings.ToLower(string(scope.Operator)),
+				strings.Join(scope.Values, ", ")))
+		}
+	}
+	return strings.Join(scopes, ", ")
+}
+
+// MatchesPod returns true if the quota charges for the pod, which it does when the pod matches every one of its scopes. This follows the
+// same rules that quota admission uses.
+func (k *KubeQuota) MatchesPod(pod *v1.Pod) bool {
+	for _, scope := range k.Scopes {
+		if !podMatchesScope(pod, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func podMatchesScope(pod *v1.Pod, scope v1.ScopedResourceSelectorRequirement) bool {
+	//nolint:exhaustive // Scopes that don't apply to pods never match them
+	switch scope.ScopeName {
+	case v1.ResourceQuotaScopeTerminating:
+		return isTerminating(pod)
+	case v1.ResourceQuotaScopeNotTerminating:
+		return !isTerminating(pod)
+	case v1.ResourceQuotaScopeBestEffort:
+		return isBestEffort(pod)
+	case v1.ResourceQuotaScopeNotBestEffort:
+		return !isBestEffort(pod)
+	case v1.ResourceQuotaScopeCrossNamespacePodAffinity:
+		return usesCrossNamespacePodAffinity(pod)
+	case v1.ResourceQuotaScopePriorityClass:
+		switch scope.Operator {
+		case v1.ScopeSelectorOpIn:
+			return slices.Contains(scope.Values, pod.Spec.PriorityClassName)
+		case v1.ScopeSelectorOpNotIn:
+			return !slices.Contains(scope.Values, pod.Spec.PriorityClassName)
+		case v1.ScopeSelectorOpExists:
+			return len(pod.Spec.PriorityClassName) != 0
+		}
+	}
+	return false
+}
+
+// isTerminating returns true for pods that have an active deadline, after which they are terminated
+func isTerminating(pod *v1.Pod) bool {
+	return pod.Spec.ActiveDeadlineSeconds != nil && *pod.Spec.ActiveDeadlineSeconds >= 0
+}
+
+// isBestEffort returns true for pods in the BestEffort QoS class, which is the case when none of its containers request or limit any cpu
+// or memory
+func isBestEffort(pod *v1.Pod) bool {
+	if pod.Status.QOSClass != "" {
+		return pod.Status.QOSClass == v1.PodQOSBestEffort
+	}
+
+	cnts := append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for idx := range cnts {
+		for _, rl := range []v1.ResourceList{cnts[idx].Resources.Requests, cnts[idx].Resources.Limits} {
+			for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				if q, ok := rl[name]; ok && q.Sign() > 0 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// usesCrossNamespacePodAffinity returns true if any of the pod's affinity or anti-affinity terms reach into other namespaces
+func usesCrossNamespacePodAffinity(pod *v1.Pod) bool {
+	if pod.Spec.Affinity == nil {
+		return false
+	}
+
+	terms := make([]v1.PodAffinityTerm, 0)
+	if pa := pod.Spec.Affinity.PodAffinity; pa != nil {
+		terms = append(terms, pa.RequiredDuringSchedulingIgnoredDuringExecution...)
+		for _, wt := range pa.PreferredDuringSchedulingIgnoredDuringExecution {
+			terms = append(terms, wt.PodAffinityTerm)
+		}
+	}
+	if paa := pod.Spec.Affinity.PodAntiAffinity; paa != nil {
+		terms = append(terms, paa.RequiredDuringSchedulingIgnoredDuringExecution...)
+		for _, wt := range paa.PreferredDuringSchedulingIgnoredDuringExecution {
+			terms = append(terms, wt.PodAffinityTerm)
+		}
+	}
+
+	for _, term := range terms {
+		if len(term.Namespaces) > 0 || term.NamespaceSelector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopedUsage adds up the pods that each scoped quota charges for. Quotas without scopes charge for every pod and claim, so their usage
+// is simply the namespace total.
+type ScopedUsage struct {
+	quotas []*KubeQuota
+	usage  map[string]*NamespaceWorkloadQuota
+}
+
+// NewScopedUsage creates an empty ScopedUsage for the quotas of a namespace
+func NewScopedUsage(ns string, kqs []*KubeQuota) *ScopedUsage {
+	s := ScopedUsage{
+		quotas: make([]*KubeQuota, 0),
+		usage:  make(map[string]*NamespaceWorkloadQuota),
+	}
+	for _, kq := range kqs {
+		if kq.Scoped() {
+			s.quotas = append(s.quotas, kq)
+			s.usage[kq.Name] = NewNamespaceWorkloadQuota(ns, false)
+		}
+	}
+	return &s
+}
+
+// AddPod adds the pod's quota to the usage of every scoped quota that charges for it
+func (s *ScopedUsage) AddPod(pod *v1.Pod, pq *PodQuota) {
+	for _, kq := range s.quotas {
+		if kq.MatchesPod(pod) {
+			s.usage[kq.Name].AddPodQuota(pq)
+		}
+	}
+}
+
+// For returns the usage of a quota, which is the total of the namespace that is passed in for quotas without scopes
+func (s *ScopedUsage) For(kq *KubeQuota, total *NamespaceWorkloadQuota) *NamespaceWorkloadQuota {
+	if nq, ok := s.usage[kq.Name]; ok && kq.Scoped() {
+		return nq
+	}
+	return total
+}
@@ -0,0 +1,136 @@
+package quota
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodMatchesScope(t *testing.T) {
+	deadline := int64(300)
+	burstable := v1.PodSpec{Containers: []v1.Container{container("app", resources("cpu", "100m"), nil)}}
+	bestEffort := v1.PodSpec{Containers: []v1.Container{container("app", nil, nil)}}
+	withDeadline := burstable
+	withDeadline.ActiveDeadlineSeconds = &deadline
+	withPriority := func(class string) v1.PodSpec {
+		spec := burstable
+		spec.PriorityClassName = class
+		return spec
+	}
+	withAffinity := func(term v1.PodAffinityTerm, anti, preferred bool) v1.PodSpec {
+		spec := burstable
+		var required []v1.PodAffinityTerm
+		var weighted []v1.WeightedPodAffinityTerm
+		if preferred {
+			weighted = []v1.WeightedPodAffinityTerm{{Weight: 1, PodAffinityTerm: term}}
+		} else {
+			required = []v1.PodAffinityTerm{term}
+		}
+		if anti {
+			spec.Affinity = &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution:  required,
+				PreferredDuringSchedulingIgnoredDuringExecution: weighted,
+			}}
+		} else {
+			spec.Affinity = &v1.Affinity{PodAffinity: &v1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution:  required,
+				PreferredDuringSchedulingIgnoredDuringExecution: weighted,
+			}}
+		}
+		return spec
+	}
+	sameNamespace := v1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}
+	otherNamespaces := v1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname", Namespaces: []string{"other"}}
+	namespaceSelector := v1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname", NamespaceSelector: &metav1.LabelSelector{}}
+
+	exists := func(name v1.ResourceQuotaScope) v1.ScopedResourceSelectorRequirement {
+		return v1.ScopedResourceSelectorRequirement{ScopeName: name, Operator: v1.ScopeSelectorOpExists}
+	}
+	priorityClass := func(op v1.ScopeSelectorOperator, values ...string) v1.ScopedResourceSelectorRequirement {
+		return v1.ScopedResourceSelectorRequirement{ScopeName: v1.ResourceQuotaScopePriorityClass, Operator: op, Values: values}
+	}
+
+	tests := []struct {
+		name  string
+		spec  v1.PodSpec
+		qos   v1.PodQOSClass
+		scope v1.ScopedResourceSelectorRequirement
+		want  bool
+	}{
+		{name: "Terminating with a deadline", spec: withDeadline, scope: exists(v1.ResourceQuotaScopeTerminating), want: true},
+		{name: "Terminating without a deadline", spec: burstable, scope: exists(v1.ResourceQuotaScopeTerminating), want: false},
+		{name: "NotTerminating with a deadline", spec: withDeadline, scope: exists(v1.ResourceQuotaScopeNotTerminating), want: false},
+		{name: "NotTerminating without a deadline", spec: burstable, scope: exists(v1.ResourceQuotaScopeNotTerminating), want: true},
+
+		{name: "BestEffort without resources", spec: bestEffort, scope: exists(v1.ResourceQuotaScopeBestEffort), want: true},
+		{name: "BestEffort with resources", spec: burstable, scope: exists(v1.ResourceQuotaScopeBestEffort), want: false},
+		{name: "BestEffort from the pod's status", spec: burstable, qos: v1.PodQOSBestEffort,
+			scope: exists(v1.ResourceQuotaScopeBestEffort), want: true},
+		{name: "NotBestEffort without resources", spec: bestEffort, scope: exists(v1.ResourceQuotaScopeNotBestEffort), want: false},
+		{name: "NotBestEffort with resources", spec: burstable, scope: exists(v1.ResourceQuotaScopeNotBestEffort), want: true},
+
+		{name: "CrossNamespacePodAffinity without affinity", spec: burstable,
+			scope: exists(v1.ResourceQuotaScopeCrossNamespacePodAffinity), want: false},
+		{name: "CrossNamespacePodAffinity within the namespace", spec: withAffinity(sameNamespace, false, false),
+			scope: exists(v1.ResourceQuotaScopeCrossNamespacePodAffinity), want: false},
+		{name: "CrossNamespacePodAffinity with namespaces", spec: withAffinity(otherNamespaces, false, false),
+			scope: exists(v1.ResourceQuotaScopeCrossNamespacePodAffinity), want: true},
+		{name: "CrossNamespacePodAffinity with a namespace selector", spec: withAffinity(namespaceSelector, false, true),
+			scope: exists(v1.ResourceQuotaScopeCrossNamespacePodAffinity), want: true},
+		{name: "CrossNamespacePodAffinity with anti-affinity", spec: withAffinity(otherNamespaces, true, true),
+			scope: exists(v1.ResourceQuotaScopeCrossNamespacePodAffinity), want: true},
+
+		{name: "PriorityClass In matching", spec: withPriority("high"), scope: priorityClass(v1.ScopeSelectorOpIn, "high", "critical"),
+			want: true},
+		{name: "PriorityClass In not matching", spec: withPriority("low"), scope: priorityClass(v1.ScopeSelectorOpIn, "high", "critical"),
+			want: false},
+		{name: "PriorityClass In without a class", spec: burstable, scope: priorityClass(v1.ScopeSelectorOpIn, "high"), want: false},
+		{name: "PriorityClass NotIn matching", spec: withPriority("low"), scope: priorityClass(v1.ScopeSelectorOpNotIn, "high"),
+			want: true},
+		{name: "PriorityClass NotIn not matching", spec: withPriority("high"), scope: priorityClass(v1.ScopeSelectorOpNotIn, "high"),
+			want: false},
+		{name: "PriorityClass NotIn without a class", spec: burstable, scope: priorityClass(v1.ScopeSelectorOpNotIn, "high"), want: true},
+		{name: "PriorityClass Exists with a class", spec: withPriority("low"), scope: priorityClass(v1.ScopeSelectorOpExists), want: true},
+		{name: "PriorityClass Exists without a class", spec: burstable, scope: priorityClass(v1.ScopeSelectorOpExists), want: false},
+		{name: "PriorityClass DoesNotExist", spec: burstable, scope: priorityClass(v1.ScopeSelectorOpDoesNotExist), want: false},
+
+		{name: "scope that doesn't apply to pods", spec: burstable,
+			scope: exists("VolumeAttributesClass"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{Spec: tt.spec, Status: v1.PodStatus{QOSClass: tt.qos}}
+			if got := podMatchesScope(pod, tt.scope); got != tt.want {
+				t.Errorf("podMatchesScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesPodNeedsEveryScope(t *testing.T) {
+	kq := ForKubeQuota(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "q"},
+		Spec: v1.ResourceQuotaSpec{
+			Hard:   resources("pods", "10"),
+			Scopes: []v1.ResourceQuotaScope{v1.ResourceQuotaScopeNotBestEffort},
+			ScopeSelector: &v1.ScopeSelector{MatchExpressions: []v1.ScopedResourceSelectorRequirement{
+				{ScopeName: v1.ResourceQuotaScopePriorityClass, Operator: v1.ScopeSelectorOpIn, Values: []string{"high"}},
+			}},
+		},
+	})
+	pod := func(class string, requests v1.ResourceList) *v1.Pod {
+		return &v1.Pod{Spec: v1.PodSpec{PriorityClassName: class, Containers: []v1.Container{container("app", requests, nil)}}}
+	}
+
+	if !kq.MatchesPod(pod("high", resources("cpu", "1"))) {
+		t.Error("MatchesPod() = false for a pod that matches every scope")
+	}
+	if kq.MatchesPod(pod("high", nil)) {
+		t.Error("MatchesPod() = true for a BestEffort pod")
+	}
+	if kq.MatchesPod(pod("low", resources("cpu", "1"))) {
+		t.Error("MatchesPod() = true for a pod with another priority class")
+	}
+}
@@ -0,0 +1,97 @@
+package quota
+
+import (
+	"github.com/aauren/kube-quota/pkg/unit"
+	v1 "k8s.io/api/core/v1"
+)
+
+// HasStatus returns true if the API server has recorded the quota's usage in its status
+func (k *KubeQuota) HasStatus() bool {
+	return k.Used != nil
+}
+
+// UsedWorkload returns the usage from the quota's status as a WorkloadQuota, so that it can be compared with the usage that was summed up
+// from pods and claims
+func (k *KubeQuota) UsedWorkload() *WorkloadQuota {
+	used := newKubeQuota(k.Name, k.Used)
+	if used.SQ.Ephemeral == nil {
+		used.SQ.Ephemeral = &EphemeralQuota{}
+	}
+
+	wq := newEmptyWorkloadQuota()
+	wq.Request.Add(used.WQ.Request)
+	wq.Limit.Add(used.WQ.Limit)
+	wq.StorageQuota.Add(used.SQ)
+	for name, val := range used.WQ.Objects {
+		wq.Objects[name] += val
+	}
+	return wq
+}
+
+// remainingQuota returns a quota that holds how much of each hard limit is left according to the quota's status. Limits that are already
+// exceeded have nothing left rather than a negative amount.
+func (k *KubeQuota) remainingQuota() *KubeQuota {
+	remaining := make(v1.ResourceList, len(k.Hard))
+	for key, hard := range k.Hard {
+		left := hard.DeepCopy()
+		if used, ok := k.Used[key]; ok {
+			left.Sub(used)
+		}
+		if left.Sign() < 0 {
+			left.Set(0)
+		}
+		remaining[key] = left
+	}
+	return newKubeQuota(k.Name, remaining)
+}
+
+// Drift returns the headers of the quota's resources where the usage that was summed up differs from the usage in the quota's status.
+// Either the quota controller hasn't caught up yet or the usage is being worked out differently than admission does.
+func (k *KubeQuota) Drift(wq *WorkloadQuota) ([]string, error) {
+	change := k.UsedWorkload()
+	change.Sub(wq)
+
+	drift := make([]string, 0)
+	for _, hdr := range k.TableHeader() {
+		if !k.Limits(hdr) || !k.tracks(hdr) {
+			continue
+		}
+		zero, err := change.isZero(hdr)
+		if err != nil {
+			return nil, err
+		}
+		if !zero {
+			drift = append(drift, hdr)
+		}
+	}
+	return drift, nil
+}
+
+// QuotaStatus shows the usage that the API server recorded in a quota's status, as a share of the quota's hard limits
+type QuotaStatus struct {
+	KQ *KubeQuota
+}
+
+func (qs *QuotaStatus) ValueForHeader(hdr string) (unit.UnitWriter, error) {
+	// Only resources that the quota limits are tracked in its status
+	if _, err := qs.KQ.ValueForHeader(hdr); err != nil {
+		return nil, err
+	}
+	if !qs.KQ.tracks(hdr) {
+		return nil, &NoValueForHeaderError{Header: hdr}
+	}
+	wu := WorkloadUsage{
+		KQ: qs.KQ,
+		WQ: qs.KQ.UsedWorkload(),
+	}
+	return wu.ValueForHeader(hdr)
+}
+
+// QuotaRemaining shows how much of each of a quota's hard limits is left according to the quota's status
+type QuotaRemaining struct {
+	KQ *KubeQuota
+}
+
+func (qr *QuotaRemaining) ValueForHeader(hdr string) (unit.UnitWriter, error) {
+	return qr.KQ.remainingQuota().ValueForHeader(hdr)
+}
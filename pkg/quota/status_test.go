@@ -0,0 +1,213 @@
+package quota
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// statusQuota returns a quota with the given hard limits and the usage recorded in its status
+func statusQuota(hard, used v1.ResourceList) *KubeQuota {
+	return ForKubeQuota(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "q"},
+		Spec:       v1.ResourceQuotaSpec{Hard: hard},
+		Status:     v1.ResourceQuotaStatus{Hard: hard, Used: used},
+	})
+}
+
+// podsWorkload returns the total of a pod for every one of the requests
+func podsWorkload(requests ...v1.ResourceList) *WorkloadQuota {
+	nq := NewNamespaceWorkloadQuota("ns", false)
+	for _, rl := range requests {
+		nq.AddPod(&v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{container("app", rl, nil)}}})
+	}
+	return nq.Sum()
+}
+
+func TestDrift(t *testing.T) {
+	hard := resources("requests.cpu", "4", "requests.memory", "4Gi", "limits.cpu", "8", "pods", "10")
+	used := resources("requests.cpu", "1", "requests.memory", "1Gi", "limits.cpu", "0", "pods", "2")
+
+	tests := []struct {
+		name string
+		wq   *WorkloadQuota
+		want []string
+	}{
+		{
+			name: "matches the status",
+			wq:   podsWorkload(resources("cpu", "500m", "memory", "512Mi"), resources("cpu", "500m", "memory", "512Mi")),
+			want: []string{},
+		},
+		{
+			name: "more than the status",
+			wq:   podsWorkload(resources("cpu", "1", "memory", "512Mi"), resources("cpu", "500m", "memory", "512Mi")),
+			want: []string{HeaderCPUReq},
+		},
+		{
+			name: "less than the status",
+			wq:   podsWorkload(resources("cpu", "1", "memory", "1Gi")),
+			want: []string{ObjectCountHeader(v1.ResourcePods)},
+		},
+		{
+			name: "nothing summed up",
+			wq:   podsWorkload(),
+			want: []string{HeaderCPUReq, HeaderMemReq, ObjectCountHeader(v1.ResourcePods)},
+		},
+	}
+
+	kq := statusQuota(hard, used)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kq.Drift(tt.wq)
+			if err != nil {
+				t.Fatalf("Drift() error = %v", err)
+			}
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Drift() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDriftIgnoresUnlimitedResources(t *testing.T) {
+	kq := statusQuota(resources("requests.cpu", "4"), resources("requests.cpu", "1"))
+	got, err := kq.Drift(podsWorkload(resources("cpu", "1", "memory", "8Gi"), resources("memory", "8Gi")))
+	if err != nil {
+		t.Fatalf("Drift() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Drift() = %q, want no drift for resources that the quota doesn't limit", got)
+	}
+}
+
+func TestQuotaRemaining(t *testing.T) {
+	tests := []struct {
+		name string
+		hard v1.ResourceList
+		used v1.ResourceList
+		hdr  string
+		want string
+	}{
+		{name: "cpu left", hard: resources("requests.cpu", "2"), used: resources("requests.cpu", "500m"), hdr: HeaderCPUReq,
+			want: "1.5 Cores"},
+		{name: "memory left", hard: resources("requests.memory", "2Gi"), used: resources("requests.memory", "1Gi"), hdr: HeaderMemReq,
+			want: "1.0 GB"},
+		{name: "count left", hard: resources("pods", "10"), used: resources("pods", "4"), hdr: ObjectCountHeader(v1.ResourcePods),
+			want: "6"},
+		{name: "nothing used", hard: resources("pods", "10"), used: resources("requests.cpu", "1"), hdr: ObjectCountHeader(v1.ResourcePods),
+			want: "10"},
+		{name: "exceeded", hard: resources("requests.cpu", "1"), used: resources("requests.cpu", "1500m"), hdr: HeaderCPUReq,
+			want: "0 Millicores"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr := &QuotaRemaining{KQ: statusQuota(tt.hard, tt.used)}
+			got, err := qr.ValueForHeader(tt.hdr)
+			if err != nil {
+				t.Fatalf("ValueForHeader() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ValueForHeader() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+
+	qr := &QuotaRemaining{KQ: statusQuota(resources("requests.cpu", "2"), resources("requests.cpu", "1"))}
+	if got, err := qr.ValueForHeader(HeaderMemReq); err == nil && got.String() != "" {
+		t.Errorf("ValueForHeader() = %q for a resource that the quota doesn't limit, want nothing", got.String())
+	}
+}
+
+func TestDelta(t *testing.T) {
+	previous := podsWorkload(resources("cpu", "1", "memory", "1Gi"))
+	current := podsWorkload(resources("cpu", "1", "memory", "1Gi"), resources("cpu", "500m"))
+	d := NewDelta(current, previous, current)
+
+	tests := []struct {
+		hdr       string
+		unchanged bool
+	}{
+		{hdr: HeaderCPUReq},
+		{hdr: ObjectCountHeader(v1.ResourcePods)},
+		{hdr: HeaderMemReq, unchanged: true},
+		{hdr: HeaderCPULim, unchanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hdr, func(t *testing.T) {
+			got, err := d.ValueForHeader(tt.hdr)
+			if err != nil {
+				t.Fatalf("ValueForHeader() error = %v", err)
+			}
+			val, err := current.ValueForHeader(tt.hdr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unchanged := got.String() == val.String(); unchanged != tt.unchanged {
+				t.Errorf("ValueForHeader() = %q, want it unchanged from %q: %v", got.String(), val.String(), tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestDriftOnlyComparesTrackedResources(t *testing.T) {
+	tests := []struct {
+		name       string
+		hard       v1.ResourceList
+		statusHard v1.ResourceList
+		used       v1.ResourceList
+		wq         *WorkloadQuota
+		untracked  string
+	}{
+		{
+			// The limits of the pod aren't part of the quota, so they aren't tracked either
+			name: "requests only",
+			hard: resources("requests.ephemeral-storage", "10Gi"),
+			used: resources("requests.ephemeral-storage", "2Gi"),
+			wq: QuotaForPod(&v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+				container("app", resources("ephemeral-storage", "2Gi"), resources("ephemeral-storage", "4Gi")),
+			}}}).Sum(),
+			untracked: HeaderEphemeralStorageLim,
+		},
+		{
+			// Memory was added to the spec, but the quota controller hasn't caught up with it yet
+			name:       "status behind the spec",
+			hard:       resources("requests.cpu", "4", "requests.memory", "8Gi"),
+			statusHard: resources("requests.cpu", "4"),
+			used:       resources("requests.cpu", "1"),
+			wq:         podsWorkload(resources("cpu", "1", "memory", "2Gi")),
+			untracked:  HeaderMemReq,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusHard := tt.statusHard
+			if statusHard == nil {
+				statusHard = tt.hard
+			}
+			kq := ForKubeQuota(&v1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "q"},
+				Spec:       v1.ResourceQuotaSpec{Hard: tt.hard},
+				Status:     v1.ResourceQuotaStatus{Hard: statusHard, Used: tt.used},
+			})
+			got, err := kq.Drift(tt.wq)
+			if err != nil {
+				t.Fatalf("Drift() error = %v", err)
+			}
+			if len(got) != 0 {
+				t.Errorf("Drift() = %q, want no drift", got)
+			}
+			var nv *NoValueForHeaderError
+			if _, err := (&QuotaStatus{KQ: kq}).ValueForHeader(tt.untracked); !errors.As(err, &nv) {
+				t.Errorf("QuotaStatus.ValueForHeader(%q) error = %v, want NoValueForHeaderError", tt.untracked, err)
+			}
+		})
+	}
+}
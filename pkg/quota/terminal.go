@@ -0,0 +1,23 @@
+package quota
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// IsTerminalPod returns true for pods that quota admission no longer charges for: pods that have Succeeded or Failed, and pods that
+// are being deleted and have gone past their deletion grace period. This follows the same rules as the pod evaluator that Kubernetes
+// uses to calculate quota usage, so that totals match what is actually enforced.
+func IsTerminalPod(pod *v1.Pod, now time.Time) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return true
+	}
+	if pod.DeletionTimestamp != nil && pod.DeletionGracePeriodSeconds != nil {
+		gracePeriod := time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+		if now.After(pod.DeletionTimestamp.Time.Add(gracePeriod)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsTerminalPod(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deleting := func(ago time.Duration, grace int64) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			DeletionTimestamp:          &metav1.Time{Time: now.Add(-ago)},
+			DeletionGracePeriodSeconds: &grace,
+		}
+	}
+
+	tests := []struct {
+		name string
+		pod  v1.Pod
+		want bool
+	}{
+		{name: "running", pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodRunning}}, want: false},
+		{name: "pending", pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending}}, want: false},
+		{name: "succeeded", pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodSucceeded}}, want: true},
+		{name: "failed", pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodFailed}}, want: true},
+		{
+			name: "deleted within its grace period",
+			pod:  v1.Pod{ObjectMeta: deleting(10*time.Second, 30), Status: v1.PodStatus{Phase: v1.PodRunning}},
+			want: false,
+		},
+		{
+			name: "deleted past its grace period",
+			pod:  v1.Pod{ObjectMeta: deleting(time.Minute, 30), Status: v1.PodStatus{Phase: v1.PodRunning}},
+			want: true,
+		},
+		{
+			name: "deleted without a grace period",
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: now.Add(-time.Hour)}},
+				Status:     v1.PodStatus{Phase: v1.PodRunning},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTerminalPod(&tt.pod, now); got != tt.want {
+				t.Errorf("IsTerminalPod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaForPodListSkipsTerminalPods(t *testing.T) {
+	pod := func(name string, phase v1.PodPhase) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{container("app", resources("cpu", "1"), nil)}},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	pl := &v1.PodList{Items: []v1.Pod{
+		pod("running", v1.PodRunning),
+		pod("succeeded", v1.PodSucceeded),
+		pod("failed", v1.PodFailed),
+	}}
+
+	nq := QuotaForPodList(pl)
+	if len(nq.PodQuotas) != 1 || nq.PodQuotas[0].Name != "running" {
+		t.Errorf("QuotaForPodList() kept %d pods, want only the running pod", len(nq.PodQuotas))
+	}
+	wq := nq.Sum()
+	if wq.Request.CPU != 1000 || wq.Objects[v1.ResourcePods] != 1 {
+		t.Errorf("QuotaForPodList() = %d millicores and %d pods, want 1000 and 1", wq.Request.CPU, wq.Objects[v1.ResourcePods])
+	}
+}
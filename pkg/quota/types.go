@@ -1,10 +1,15 @@
 package quota
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	kubequota "github.com/aauren/kube-quota/pkg"
 	"github.com/aauren/kube-quota/pkg/unit"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -14,8 +19,35 @@ const (
 	HeaderMemReq              = "Mem Request"
 	HeaderCPULim              = "CPU Limit"
 	HeaderMemLim              = "Mem Limit"
+	HeaderStorageReq          = "Storage Request"
+	HeaderPVCs                = "PVCs"
+
+	// HeaderRequestSuffix and HeaderLimitSuffix follow the name of an extended resource in the headers of its columns
+	HeaderRequestSuffix = "Request"
+	HeaderLimitSuffix   = "Limit"
+	// HeaderCountSuffix follows the name of an object count, such as pods or deployments.apps, in the header of its column
+	HeaderCountSuffix = "Count"
 )
 
+// StorageClassHeader returns the header of a persistent storage column (HeaderStorageReq or HeaderPVCs) for a single storage class
+func StorageClassHeader(hdr, class string) string {
+	return fmt.Sprintf("%s (%s)", hdr, class)
+}
+
+// parseStorageHeader splits a persistent storage header into its base header and the storage class that it is for, which is empty for
+// the columns that cover every storage class. ok is false for headers that aren't persistent storage headers.
+func parseStorageHeader(hdr string) (base, class string, ok bool) {
+	for _, base := range []string{HeaderStorageReq, HeaderPVCs} {
+		if hdr == base {
+			return base, "", true
+		}
+		if strings.HasPrefix(hdr, base+" (") && strings.HasSuffix(hdr, ")") {
+			return base, strings.TrimSuffix(strings.TrimPrefix(hdr, base+" ("), ")"), true
+		}
+	}
+	return "", "", false
+}
+
 type NoValueForHeaderError struct {
 	Header string
 }
@@ -27,11 +59,57 @@ func (n *NoValueForHeaderError) Error() string {
 type ComputeQuota struct {
 	CPU kubequota.CPUMilicore
 	Mem kubequota.MemBytes
+	// Extended holds every other compute resource, such as GPUs or hugepages, by resource name
+	Extended map[v1.ResourceName]int64
+}
+
+func (c *ComputeQuota) setExtended(name v1.ResourceName, val resource.Quantity) {
+	if c.Extended == nil {
+		c.Extended = make(map[v1.ResourceName]int64)
+	}
+	c.Extended[name] = val.Value()
+}
+
+// extendedValue returns the value of an extended resource in the unit that it should be shown in
+func extendedValue(name v1.ResourceName, val int64) (unit.UnitWriter, error) {
+	if strings.HasPrefix(string(name), v1.ResourceHugePagesPrefix) {
+		return unit.NewUnitWriter(kubequota.MemBytes(val), unit.Bytes)
+	}
+	return unit.NewUnitWriter(kubequota.ResourceNum(val), unit.Count)
+}
+
+// extendedPercentUnit returns the unit that the usage of an extended resource should be shown in
+func extendedPercentUnit(name v1.ResourceName) unit.FormatUnit {
+	if strings.HasPrefix(string(name), v1.ResourceHugePagesPrefix) {
+		return unit.PercentBytes
+	}
+	return unit.PercentCount
+}
+
+// ExtendedHeader returns the header of the column for the requests or limits (HeaderRequestSuffix or HeaderLimitSuffix) of an extended
+// resource
+func ExtendedHeader(name v1.ResourceName, suffix string) string {
+	return string(name) + " " + suffix
+}
+
+// parseExtendedHeader returns the extended resource that a header is for and whether it is for its limits rather than its requests. ok
+// is false for headers that aren't for an extended resource.
+func parseExtendedHeader(hdr string) (name v1.ResourceName, limit, ok bool) {
+	if n, found := strings.CutSuffix(hdr, " "+HeaderRequestSuffix); found && isExtendedResource(v1.ResourceName(n)) {
+		return v1.ResourceName(n), false, true
+	}
+	if n, found := strings.CutSuffix(hdr, " "+HeaderLimitSuffix); found && isExtendedResource(v1.ResourceName(n)) {
+		return v1.ResourceName(n), true, true
+	}
+	return "", false, false
 }
 
 type StorageQuota struct {
 	StorageClasses map[string]*StorageClassQuota
 	Ephemeral      *EphemeralQuota
+	// AllClasses holds the persistent storage and number of claims across every storage class, for quotas it is nil unless the quota
+	// limits either of them
+	AllClasses *StorageClassQuota
 }
 
 func (s *StorageQuota) HasEphemeralQuota() bool {
@@ -42,20 +120,91 @@ func (s *StorageQuota) HasStorageClassQuota() bool {
 	return len(s.StorageClasses) > 0
 }
 
+func (s *StorageQuota) HasPersistentQuota() bool {
+	return s.AllClasses != nil
+}
+
 func (s *StorageQuota) TableHeader() []string {
 	header := make([]string, 0)
 	if s.HasEphemeralQuota() {
 		header = append(header, HeaderEphemeralStorageReq, HeaderEphemeralStorageLim)
 	}
+	if s.HasPersistentQuota() {
+		header = append(header, HeaderStorageReq, HeaderPVCs)
+	}
+	classes := make([]string, 0, len(s.StorageClasses))
+	for class := range s.StorageClasses {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		header = append(header, StorageClassHeader(HeaderStorageReq, class), StorageClassHeader(HeaderPVCs, class))
+	}
 	return header
 }
 
+// persistentForHeader returns the persistent storage that a header is for along with the header without its storage class. The
+// StorageClassQuota is nil when the header names storage that isn't tracked, and ok is false for headers that aren't persistent storage
+// headers at all.
+func (s *StorageQuota) persistentForHeader(hdr string) (sc *StorageClassQuota, base string, ok bool) {
+	base, class, ok := parseStorageHeader(hdr)
+	if !ok || s == nil {
+		return nil, base, ok
+	}
+	if class == "" {
+		return s.AllClasses, base, true
+	}
+	return s.StorageClasses[class], base, true
+}
+
+// storageHardKeys returns the hard limits that a persistent storage column is for, given its base header and storage class (which is
+// empty for the columns that cover every storage class)
+func storageHardKeys(base, class string) []v1.ResourceName {
+	prefix := ""
+	if class != "" {
+		prefix = class + storageClassSuffix
+	}
+	if base == HeaderStorageReq {
+		return []v1.ResourceName{v1.ResourceName(prefix + string(v1.ResourceRequestsStorage))}
+	}
+	keys := []v1.ResourceName{v1.ResourceName(prefix + string(v1.ResourcePersistentVolumeClaims))}
+	if class == "" {
+		// Claims can also be limited as an object count, which is the same as limiting them bare
+		keys = append(keys, objectCountPrefix+v1.ResourcePersistentVolumeClaims)
+	}
+	return keys
+}
+
+// StorageClassQuota holds persistent storage, either for a single storage class or across all of them
 type StorageClassQuota struct {
 	Name     string
 	Requests kubequota.StorageBytes
 	Claims   kubequota.ClaimsNum
 }
 
+// ValueForHeader returns the value for a persistent storage header without its storage class (HeaderStorageReq or HeaderPVCs)
+func (s *StorageClassQuota) ValueForHeader(hdr string) (unit.UnitWriter, error) {
+	switch hdr {
+	case HeaderStorageReq:
+		return unit.NewUnitWriter(s.Requests, unit.Bytes)
+	case HeaderPVCs:
+		return unit.NewUnitWriter(s.Claims, unit.Count)
+	}
+
+	return nil, &NoValueForHeaderError{Header: hdr}
+}
+
+func (s *StorageClassQuota) ComparativeUsage(hdr string, totalQuota *StorageClassQuota) (*kubequota.Percentage, error) {
+	switch hdr {
+	case HeaderStorageReq:
+		return &kubequota.Percentage{Parts: int64(s.Requests), Whole: int64(totalQuota.Requests)}, nil
+	case HeaderPVCs:
+		return &kubequota.Percentage{Parts: int64(s.Claims), Whole: int64(totalQuota.Claims)}, nil
+	}
+
+	return nil, &NoValueForHeaderError{Header: hdr}
+}
+
 type EphemeralQuota struct {
 	Requests kubequota.StorageBytes
 	Limits   kubequota.StorageBytes
@@ -90,8 +239,13 @@ type WorkloadQuota struct {
 	Request      *ComputeQuota
 	Limit        *ComputeQuota
 	StorageQuota *StorageQuota
+	// Objects holds the number of objects by object count name (see ObjectCountHeader), it is nil for workloads that don't count
+	// objects, such as single containers
+	Objects map[v1.ResourceName]int64
 }
 
+// TableHeader returns the columns that the workload has values for. Object counts are left out, since every pod counts as one and the
+// columns are only of interest when a quota limits them.
 func (w *WorkloadQuota) TableHeader() []string {
 	header := make([]string, 0)
 	if w.Request != nil {
@@ -100,9 +254,66 @@ func (w *WorkloadQuota) TableHeader() []string {
 	if w.Limit != nil {
 		header = append(header, HeaderCPULim, HeaderMemLim)
 	}
+	header = append(header, w.extendedHeaders()...)
+	// Most workloads don't set ephemeral storage, so only ask for its columns when there is something to show
+	if w.HasEphemeralStorage() {
+		header = append(header, HeaderEphemeralStorageReq, HeaderEphemeralStorageLim)
+	}
+	if w.HasPersistentStorage() {
+		header = append(header, HeaderStorageReq, HeaderPVCs)
+	}
 	return header
 }
 
+// HasPersistentStorage returns true if the workload has any PersistentVolumeClaims
+func (w *WorkloadQuota) HasPersistentStorage() bool {
+	if w.StorageQuota == nil || !w.StorageQuota.HasPersistentQuota() {
+		return false
+	}
+	return w.StorageQuota.AllClasses.Requests != 0 || w.StorageQuota.AllClasses.Claims != 0
+}
+
+// extendedHeaders returns the headers for every extended resource that the workload requests or limits, in a stable order
+func (w *WorkloadQuota) extendedHeaders() []string {
+	var req, lim map[v1.ResourceName]int64
+	if w.Request != nil {
+		req = w.Request.Extended
+	}
+	if w.Limit != nil {
+		lim = w.Limit.Extended
+	}
+
+	names := make([]string, 0, len(req)+len(lim))
+	for name := range req {
+		names = append(names, string(name))
+	}
+	for name := range lim {
+		if _, ok := req[name]; !ok {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+
+	header := make([]string, 0)
+	for _, name := range names {
+		if _, ok := req[v1.ResourceName(name)]; ok {
+			header = append(header, ExtendedHeader(v1.ResourceName(name), HeaderRequestSuffix))
+		}
+		if _, ok := lim[v1.ResourceName(name)]; ok {
+			header = append(header, ExtendedHeader(v1.ResourceName(name), HeaderLimitSuffix))
+		}
+	}
+	return header
+}
+
+// HasEphemeralStorage returns true if the workload requests or limits any ephemeral storage
+func (w *WorkloadQuota) HasEphemeralStorage() bool {
+	if w.StorageQuota == nil || !w.StorageQuota.HasEphemeralQuota() {
+		return false
+	}
+	return w.StorageQuota.Ephemeral.Requests != 0 || w.StorageQuota.Ephemeral.Limits != 0
+}
+
 func (w *WorkloadQuota) ValueForHeader(hdr string) (unit.UnitWriter, error) {
 	switch hdr {
 	case HeaderCPUReq:
@@ -113,6 +324,25 @@ func (w *WorkloadQuota) ValueForHeader(hdr string) (unit.UnitWriter, error) {
 		return unit.NewUnitWriter(w.Limit.CPU, unit.Cores)
 	case HeaderMemLim:
 		return unit.NewUnitWriter(w.Limit.Mem, unit.Bytes)
+	case HeaderEphemeralStorageReq, HeaderEphemeralStorageLim:
+		return w.StorageQuota.Ephemeral.ValueForHeader(hdr)
+	}
+	if sc, base, ok := w.StorageQuota.persistentForHeader(hdr); ok {
+		if sc == nil {
+			// The workload doesn't use any storage of this class
+			sc = &StorageClassQuota{}
+		}
+		return sc.ValueForHeader(base)
+	}
+	if name, limit, ok := parseExtendedHeader(hdr); ok {
+		// Anything the workload doesn't use shows as 0
+		if limit {
+			return extendedValue(name, w.Limit.Extended[name])
+		}
+		return extendedValue(name, w.Request.Extended[name])
+	}
+	if name, ok := parseObjectCountHeader(hdr); ok {
+		return w.objectValue(name)
 	}
 
 	return nil, &NoValueForHeaderError{Header: hdr}
@@ -128,11 +358,49 @@ func (w *WorkloadQuota) ComparativeUsage(hdr string, totalQuota *WorkloadQuota)
 		return &kubequota.Percentage{Parts: int64(w.Limit.CPU), Whole: int64(totalQuota.Limit.CPU)}, nil
 	case HeaderMemLim:
 		return &kubequota.Percentage{Parts: int64(w.Limit.Mem), Whole: int64(totalQuota.Limit.Mem)}, nil
+	case HeaderEphemeralStorageReq, HeaderEphemeralStorageLim:
+		return w.StorageQuota.Ephemeral.ComparativeUsage(hdr, totalQuota.StorageQuota.Ephemeral)
+	}
+	if sc, base, ok := w.StorageQuota.persistentForHeader(hdr); ok {
+		total, _, _ := totalQuota.StorageQuota.persistentForHeader(hdr)
+		if sc == nil {
+			sc = &StorageClassQuota{}
+		}
+		if total == nil {
+			total = &StorageClassQuota{}
+		}
+		return sc.ComparativeUsage(base, total)
+	}
+	if name, limit, ok := parseExtendedHeader(hdr); ok {
+		if limit {
+			return &kubequota.Percentage{Parts: w.Limit.Extended[name], Whole: totalQuota.Limit.Extended[name]}, nil
+		}
+		return &kubequota.Percentage{Parts: w.Request.Extended[name], Whole: totalQuota.Request.Extended[name]}, nil
+	}
+	if name, ok := parseObjectCountHeader(hdr); ok {
+		if _, counted := w.Objects[name]; !counted {
+			return nil, &NoValueForHeaderError{Header: hdr}
+		}
+		return &kubequota.Percentage{Parts: w.Objects[name], Whole: totalQuota.Objects[name]}, nil
 	}
 
 	return nil, &NoValueForHeaderError{Header: hdr}
 }
 
+// isZero returns true if the workload holds nothing for the resource shown in a column
+func (w *WorkloadQuota) isZero(hdr string) (bool, error) {
+	// The parts of a comparison are the raw amount
+	p, err := w.ComparativeUsage(hdr, w)
+	var notFound *NoValueForHeaderError
+	if errors.As(err, &notFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return p.Parts == 0, nil
+}
+
 func (w *WorkloadQuota) ComparativeUsageAsWriter(hdr string, totalQuota *WorkloadQuota) (unit.UnitWriter, error) {
 	p, err := w.ComparativeUsage(hdr, totalQuota)
 	if err != nil {
@@ -142,37 +410,163 @@ func (w *WorkloadQuota) ComparativeUsageAsWriter(hdr string, totalQuota *Workloa
 	switch hdr {
 	case HeaderCPUReq, HeaderCPULim:
 		return unit.NewUnitWriter(p, unit.PercentCores)
-	case HeaderMemReq, HeaderMemLim:
+	case HeaderMemReq, HeaderMemLim, HeaderEphemeralStorageReq, HeaderEphemeralStorageLim:
 		return unit.NewUnitWriter(p, unit.PercentBytes)
 	}
+	if base, _, ok := parseStorageHeader(hdr); ok {
+		if base == HeaderPVCs {
+			return unit.NewUnitWriter(p, unit.PercentCount)
+		}
+		return unit.NewUnitWriter(p, unit.PercentBytes)
+	}
+	if name, _, ok := parseExtendedHeader(hdr); ok {
+		return unit.NewUnitWriter(p, extendedPercentUnit(name))
+	}
+	if _, ok := parseObjectCountHeader(hdr); ok {
+		return unit.NewUnitWriter(p, unit.PercentCount)
+	}
 
 	return nil, &NoValueForHeaderError{Header: hdr}
 }
 
+// hasExtended returns true if the workload sets the extended resource that the header is for
+func (w *WorkloadQuota) hasExtended(hdr string) bool {
+	name, limit, _ := parseExtendedHeader(hdr)
+	if limit {
+		_, ok := w.Limit.Extended[name]
+		return ok
+	}
+	_, ok := w.Request.Extended[name]
+	return ok
+}
+
 type PodQuota struct {
 	Name           string
 	Namespace      string
 	WorkloadQuotas []*WorkloadQuota
 }
 
+// NamespaceWorkloadQuota aggregates the quota reserved by the pods of a namespace. Pods are summed as they are added so that the total
+// is always available, while the individual PodQuotas are only kept around when they were asked for. This keeps memory bounded for
+// namespaces with very large numbers of pods.
 type NamespaceWorkloadQuota struct {
 	Name      string
 	Namespace string
 	PodQuotas []*PodQuota
+
+	keepPods bool
+	total    *WorkloadQuota
+	// podIndex maps the namespace/name of every kept pod to its position in PodQuotas so that pods can be updated and deleted
+	podIndex map[string]int
+	// claims holds the storage of every kept PersistentVolumeClaim by namespace/name so that claims can be updated and deleted
+	claims map[string]*WorkloadQuota
+	// objects holds the object counts that were set with SetObjectCount, they are added to the total when it is summed
+	objects map[v1.ResourceName]int64
 }
 
 type KubeQuota struct {
-	WQ *WorkloadQuota
-	SQ *StorageQuota
+	Name string
+	// Hard holds the quota's hard limits as they were given, with any bare resource names given their requests. prefix
+	Hard v1.ResourceList
+	// Used holds the usage from the quota's status, normalized the same way as Hard. It is nil when the quota has no status yet.
+	Used v1.ResourceList
+	// StatusHard holds the hard limits from the quota's status, which are the resources that Used tracks. It is nil when the status
+	// doesn't have them.
+	StatusHard v1.ResourceList
+	// Scopes holds the quota's scopes and scope selector, a quota with scopes only charges for the pods that match all of them
+	Scopes []v1.ScopedResourceSelectorRequirement
+	WQ     *WorkloadQuota
+	SQ     *StorageQuota
+}
+
+// Limits returns true if the quota limits the resource shown in a column. Quotas that leave a resource alone show an empty value for
+// it, and nothing is compared against them.
+func (k *KubeQuota) Limits(hdr string) bool {
+	return hasAnyKey(k.Hard, hardKeysForHeader(hdr))
+}
+
+// tracks returns true if the quota's status records the usage of the resource shown in a column. The quota controller only tracks the
+// resources in status.hard, which lags behind the spec until the controller catches up with a change. Quotas without a status.hard,
+// for instance ones that were written out by hand, are assumed to track everything that they limit.
+func (k *KubeQuota) tracks(hdr string) bool {
+	if k.StatusHard == nil {
+		return k.Limits(hdr)
+	}
+	return hasAnyKey(k.StatusHard, hardKeysForHeader(hdr))
+}
+
+// hardKeysForHeader returns the hard limits that a column is worked out from, any one of them is enough for a quota to limit it
+func hardKeysForHeader(hdr string) []v1.ResourceName {
+	switch hdr {
+	case HeaderCPUReq:
+		return []v1.ResourceName{v1.ResourceRequestsCPU}
+	case HeaderMemReq:
+		return []v1.ResourceName{v1.ResourceRequestsMemory}
+	case HeaderCPULim:
+		return []v1.ResourceName{v1.ResourceLimitsCPU}
+	case HeaderMemLim:
+		return []v1.ResourceName{v1.ResourceLimitsMemory}
+	case HeaderEphemeralStorageReq:
+		return []v1.ResourceName{v1.ResourceRequestsEphemeralStorage, v1.ResourceEphemeralStorage}
+	case HeaderEphemeralStorageLim:
+		return []v1.ResourceName{v1.ResourceLimitsEphemeralStorage}
+	}
+	if base, class, ok := parseStorageHeader(hdr); ok {
+		return storageHardKeys(base, class)
+	}
+	if name, limit, ok := parseExtendedHeader(hdr); ok {
+		if limit {
+			return []v1.ResourceName{v1.ResourceName(limitsPrefix + string(name))}
+		}
+		return []v1.ResourceName{v1.ResourceName(requestsPrefix + string(name))}
+	}
+	if name, ok := parseObjectCountHeader(hdr); ok {
+		// Legacy object counts can be given either bare or with a count/ prefix
+		return []v1.ResourceName{name, objectCountPrefix + name}
+	}
+	return nil
+}
+
+// hasAnyKey returns true if the resource list holds any of the given resources
+func hasAnyKey(rl v1.ResourceList, keys []v1.ResourceName) bool {
+	for _, key := range keys {
+		if _, ok := rl[key]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (k *KubeQuota) ValueForHeader(hdr string) (unit.UnitWriter, error) {
 	switch hdr {
 	case HeaderCPUReq, HeaderMemReq, HeaderCPULim, HeaderMemLim:
+		if !k.Limits(hdr) {
+			return nil, &NoValueForHeaderError{Header: hdr}
+		}
 		return k.WQ.ValueForHeader(hdr)
 	case HeaderEphemeralStorageReq, HeaderEphemeralStorageLim:
+		if !k.Limits(hdr) {
+			return nil, &NoValueForHeaderError{Header: hdr}
+		}
 		return k.SQ.Ephemeral.ValueForHeader(hdr)
 	}
+	if _, _, ok := parseExtendedHeader(hdr); ok {
+		// Extended resources that the quota doesn't limit are left empty
+		if !k.WQ.hasExtended(hdr) {
+			return nil, &NoValueForHeaderError{Header: hdr}
+		}
+		return k.WQ.ValueForHeader(hdr)
+	}
+	if _, ok := parseObjectCountHeader(hdr); ok {
+		// Objects that the quota doesn't count are left empty
+		if !k.WQ.hasObjectCount(hdr) {
+			return nil, &NoValueForHeaderError{Header: hdr}
+		}
+		return k.WQ.ValueForHeader(hdr)
+	}
+	if sc, base, ok := k.SQ.persistentForHeader(hdr); ok && sc != nil && k.Limits(hdr) {
+		return sc.ValueForHeader(base)
+	}
 
 	return nil, &NoValueForHeaderError{Header: hdr}
 }
@@ -194,8 +588,9 @@ func (k *KubeQuota) TableHeader() []string {
 	if k.HasWorkloadQuota() {
 		header = append(header, k.WQ.TableHeader()...)
 	}
-	if k.HasEphemeralQuota() {
-		header = append(header, k.SQ.TableHeader()...)
+	header = append(header, k.SQ.TableHeader()...)
+	if k.HasWorkloadQuota() {
+		header = append(header, k.WQ.objectHeaders()...)
 	}
 	return header
 }
@@ -206,17 +601,34 @@ type QuotaUsage struct {
 }
 
 func (qu *QuotaUsage) ValueForHeader(hdr string) (unit.UnitWriter, error) {
-	wq := qu.NWQ.Sum()
-	switch hdr {
-	case HeaderCPUReq, HeaderMemReq, HeaderCPULim, HeaderMemLim:
-		return wq.ComparativeUsageAsWriter(hdr, qu.KQ.WQ)
-	case HeaderEphemeralStorageReq, HeaderEphemeralStorageLim:
-		p, err := wq.StorageQuota.Ephemeral.ComparativeUsage(hdr, qu.KQ.SQ.Ephemeral)
+	wu := WorkloadUsage{
+		KQ: qu.KQ,
+		WQ: qu.NWQ.Sum(),
+	}
+	return wu.ValueForHeader(hdr)
+}
+
+// WorkloadUsage compares a single WorkloadQuota, such as the sum of one pod or one container, against the quota so that its share of
+// the quota can be shown
+type WorkloadUsage struct {
+	KQ *KubeQuota
+	WQ *WorkloadQuota
+}
+
+func (wu *WorkloadUsage) ValueForHeader(hdr string) (unit.UnitWriter, error) {
+	switch {
+	case !wu.KQ.Limits(hdr):
+		// Resources that the quota doesn't limit have nothing to compare against, so just show the value
+		return wu.WQ.ValueForHeader(hdr)
+	case hdr == HeaderEphemeralStorageReq || hdr == HeaderEphemeralStorageLim:
+		p, err := wu.WQ.StorageQuota.Ephemeral.ComparativeUsage(hdr, wu.KQ.SQ.Ephemeral)
 		if err != nil {
 			return nil, err
 		}
 		return unit.NewUnitWriter(p, unit.PercentBytes)
 	}
-
-	return nil, &NoValueForHeaderError{Header: hdr}
+	if _, _, ok := parseStorageHeader(hdr); ok {
+		return wu.WQ.ComparativeUsageAsWriter(hdr, &WorkloadQuota{StorageQuota: wu.KQ.SQ})
+	}
+	return wu.WQ.ComparativeUsageAsWriter(hdr, wu.KQ.WQ)
 }
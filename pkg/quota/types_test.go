@@ -0,0 +1,202 @@
+package quota
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aauren/kube-quota/pkg/unit"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseExtendedHeader(t *testing.T) {
+	tests := []struct {
+		hdr       string
+		wantName  v1.ResourceName
+		wantLimit bool
+		wantOK    bool
+	}{
+		{hdr: "nvidia.com/gpu Request", wantName: "nvidia.com/gpu", wantOK: true},
+		{hdr: "nvidia.com/gpu Limit", wantName: "nvidia.com/gpu", wantLimit: true, wantOK: true},
+		{hdr: "hugepages-2Mi Request", wantName: "hugepages-2Mi", wantOK: true},
+		{hdr: "hugepages-1Gi Limit", wantName: "hugepages-1Gi", wantLimit: true, wantOK: true},
+		{hdr: HeaderCPUReq},
+		{hdr: HeaderMemLim},
+		{hdr: HeaderEphemeralStorageReq},
+		{hdr: "nvidia.com/gpu"},
+		{hdr: "nvidia.com/gpu Count"},
+		{hdr: "count/deployments.apps Request"},
+		{hdr: "gold.storageclass.storage.k8s.io/requests.storage Request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hdr, func(t *testing.T) {
+			name, limit, ok := parseExtendedHeader(tt.hdr)
+			if name != tt.wantName || limit != tt.wantLimit || ok != tt.wantOK {
+				t.Errorf("parseExtendedHeader() = %q, %v, %v, want %q, %v, %v", name, limit, ok, tt.wantName, tt.wantLimit, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseStorageHeader(t *testing.T) {
+	tests := []struct {
+		hdr       string
+		wantBase  string
+		wantClass string
+		wantOK    bool
+	}{
+		{hdr: HeaderStorageReq, wantBase: HeaderStorageReq, wantOK: true},
+		{hdr: HeaderPVCs, wantBase: HeaderPVCs, wantOK: true},
+		{hdr: "Storage Request (gold)", wantBase: HeaderStorageReq, wantClass: "gold", wantOK: true},
+		{hdr: "PVCs (fast-ssd)", wantBase: HeaderPVCs, wantClass: "fast-ssd", wantOK: true},
+		{hdr: HeaderEphemeralStorageReq},
+		{hdr: "Storage Request (gold"},
+		{hdr: "Storage Requests"},
+		{hdr: HeaderCPUReq},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hdr, func(t *testing.T) {
+			base, class, ok := parseStorageHeader(tt.hdr)
+			if base != tt.wantBase || class != tt.wantClass || ok != tt.wantOK {
+				t.Errorf("parseStorageHeader() = %q, %q, %v, want %q, %q, %v", base, class, ok, tt.wantBase, tt.wantClass, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWorkloadUsageValueForHeader(t *testing.T) {
+	kq := ForKubeQuota(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "q"},
+		Spec: v1.ResourceQuotaSpec{Hard: resources(
+			"requests.cpu", "2",
+			"requests.ephemeral-storage", "10Gi",
+			"requests.nvidia.com/gpu", "4",
+			"gold.storageclass.storage.k8s.io/requests.storage", "100Gi",
+			"count/deployments.apps", "4",
+		)},
+	})
+	wq := QuotaForPod(&v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+		container("app", resources("cpu", "500m", "memory", "1Gi", "ephemeral-storage", "1Gi", "nvidia.com/gpu", "1",
+			"example.com/fpga", "1"), nil),
+	}}}).Sum()
+	wq.Objects["deployments.apps"] = 1
+	wq.Objects["secrets"] = 3
+	wq.StorageQuota.StorageClasses["gold"] = &StorageClassQuota{Name: "gold", Requests: 25 * 1024 * 1024 * 1024, Claims: 1}
+	wq.StorageQuota.StorageClasses["silver"] = &StorageClassQuota{Name: "silver", Requests: 10 * 1024 * 1024 * 1024, Claims: 1}
+	wu := &WorkloadUsage{KQ: kq, WQ: wq}
+
+	tests := []struct {
+		hdr  string
+		want string
+	}{
+		// Resources that the quota limits are shown along with their share of it
+		{hdr: HeaderCPUReq, want: "500 Millicores (25.00%)"},
+		{hdr: HeaderEphemeralStorageReq, want: "1.0 GB (10.00%)"},
+		{hdr: "nvidia.com/gpu Request", want: "1 (25.00%)"},
+		{hdr: "deployments.apps Count", want: "1 (25.00%)"},
+		{hdr: "Storage Request (gold)", want: "25.0 GB (25.00%)"},
+		// Everything else is shown as it is
+		{hdr: HeaderMemReq, want: "1.0 GB"},
+		{hdr: HeaderCPULim, want: "0 Millicores"},
+		{hdr: "example.com/fpga Request", want: "1"},
+		{hdr: "secrets Count", want: "3"},
+		{hdr: "Storage Request (silver)", want: "10.0 GB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hdr, func(t *testing.T) {
+			got, err := wu.ValueForHeader(tt.hdr)
+			if err != nil {
+				t.Fatalf("ValueForHeader() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ValueForHeader() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown header", func(t *testing.T) {
+		var nv *NoValueForHeaderError
+		if _, err := wu.ValueForHeader("Bogus"); !errors.As(err, &nv) {
+			t.Errorf("ValueForHeader() error = %v, want NoValueForHeaderError", err)
+		}
+	})
+}
+
+func TestRequestsOnlyEphemeralQuota(t *testing.T) {
+	for _, key := range []string{"requests.ephemeral-storage", "ephemeral-storage"} {
+		t.Run(key, func(t *testing.T) {
+			kq := statusQuota(resources(key, "10Gi"), resources(key, "2Gi"))
+			wq := QuotaForPod(&v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+				container("app", resources("ephemeral-storage", "2Gi"), resources("ephemeral-storage", "4Gi")),
+			}}}).Sum()
+
+			if !kq.Limits(HeaderEphemeralStorageReq) || kq.Limits(HeaderEphemeralStorageLim) {
+				t.Errorf("Limits() = %v, %v, want only the request to be limited", kq.Limits(HeaderEphemeralStorageReq),
+					kq.Limits(HeaderEphemeralStorageLim))
+			}
+
+			// The limit isn't part of the quota, so the quota has nothing for it and the workload's limit is shown as it is
+			var nv *NoValueForHeaderError
+			for name, hv := range map[string]interface {
+				ValueForHeader(string) (unit.UnitWriter, error)
+			}{"quota": kq, "status": &QuotaStatus{KQ: kq}, "remaining": &QuotaRemaining{KQ: kq}} {
+				if _, err := hv.ValueForHeader(HeaderEphemeralStorageLim); !errors.As(err, &nv) {
+					t.Errorf("%s ValueForHeader(%q) error = %v, want NoValueForHeaderError", name, HeaderEphemeralStorageLim, err)
+				}
+			}
+
+			wu := &WorkloadUsage{KQ: kq, WQ: wq}
+			for hdr, want := range map[string]string{HeaderEphemeralStorageReq: "2.0 GB (20.00%)", HeaderEphemeralStorageLim: "4.0 GB"} {
+				got, err := wu.ValueForHeader(hdr)
+				if err != nil || got.String() != want {
+					t.Errorf("WorkloadUsage.ValueForHeader(%q) = %v, %v, want %q", hdr, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageClassQuotaLimitsOnlyItsKeys(t *testing.T) {
+	kq := ForKubeQuota(&v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "q"},
+		Spec: v1.ResourceQuotaSpec{Hard: resources(
+			"fast.storageclass.storage.k8s.io/requests.storage", "10Gi",
+			"count/persistentvolumeclaims", "5",
+		)},
+	})
+	class := "fast"
+	wq := QuotaForClaim(&v1.PersistentVolumeClaim{Spec: v1.PersistentVolumeClaimSpec{
+		StorageClassName: &class,
+		Resources:        v1.VolumeResourceRequirements{Requests: resources("storage", "1Gi")},
+	}})
+
+	tests := []struct {
+		hdr         string
+		wantLimited bool
+		want        string
+	}{
+		{hdr: "Storage Request (fast)", wantLimited: true, want: "1.0 GB (10.00%)"},
+		{hdr: "PVCs (fast)", want: "1"},
+		{hdr: HeaderStorageReq, want: "1.0 GB"},
+		{hdr: HeaderPVCs, wantLimited: true, want: "1 (20.00%)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hdr, func(t *testing.T) {
+			if got := kq.Limits(tt.hdr); got != tt.wantLimited {
+				t.Errorf("Limits() = %v, want %v", got, tt.wantLimited)
+			}
+			_, err := kq.ValueForHeader(tt.hdr)
+			var nv *NoValueForHeaderError
+			if tt.wantLimited == errors.As(err, &nv) {
+				t.Errorf("KubeQuota.ValueForHeader() error = %v, want a value only when the quota limits the column", err)
+			}
+			got, err := (&WorkloadUsage{KQ: kq, WQ: wq}).ValueForHeader(tt.hdr)
+			if err != nil || got.String() != tt.want {
+				t.Errorf("WorkloadUsage.ValueForHeader() = %v, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// IsSnapshot returns true if the path is a snapshot archive or a directory that holds a snapshot
+func IsSnapshot(path string) bool {
+	if IsArchive(path) {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(path, MetadataFilename))
+	return err == nil
+}
+
+// Read reads the metadata and every object that is stored in a snapshot archive or directory
+func Read(path string) (*Metadata, []runtime.Object, error) {
+	var files map[string][]byte
+	var err error
+	if IsArchive(path) {
+		files, err = readArchive(path)
+	} else {
+		files, err = readDir(path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mdData, ok := files[MetadataFilename]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a kube-quota snapshot, it has no %s", path, MetadataFilename)
+	}
+	var md Metadata
+	err = json.Unmarshal(mdData, &md)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse snapshot metadata: %w", err)
+	}
+	if md.FormatVersion > FormatVersion {
+		return nil, nil, fmt.Errorf("snapshot format version %d is newer than the supported version %d, please upgrade kube-quota",
+			md.FormatVersion, FormatVersion)
+	}
+
+	objs := make([]runtime.Object, 0)
+	for name, data := range files {
+		if name == MetadataFilename {
+			continue
+		}
+		fileObjs, err := kubernetes.ReadObjects(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read objects from %s: %w", name, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+
+	return &md, objs, nil
+}
+
+func readDir(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+
+	return files, err
+}
+
+func readArchive(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[hdr.Name] = data
+	}
+}
@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// roundTripYAML holds a quota that counts a custom resource, so that replaying the snapshot needs the custom resource's kind as well
+const roundTripYAML = `apiVersion: v1
+kind: Namespace
+metadata: {name: dev}
+---
+apiVersion: v1
+kind: Pod
+metadata: {name: app, namespace: dev}
+spec:
+  containers:
+  - {name: app, image: app, resources: {requests: {cpu: "1"}}}
+---
+apiVersion: v1
+kind: ResourceQuota
+metadata: {name: widgets, namespace: dev}
+spec:
+  hard: {count/widgets.example.com: "5"}
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata: {name: spinner, namespace: dev}
+spec: {size: large}
+`
+
+// objectKeys describes every object as kind/namespace/name so that the objects read back from a snapshot can be compared
+func objectKeys(t *testing.T, objs []runtime.Object) []string {
+	t.Helper()
+	keys := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil {
+			kind = gvks[0].Kind
+		}
+		keys = append(keys, kind+"/"+accessor.GetNamespace()+"/"+accessor.GetName())
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func captureRoundTrip(t *testing.T) *Snapshot {
+	t.Helper()
+	objs, err := kubernetes.ReadObjects(strings.NewReader(roundTripYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := kubernetes.NewClientForObjects(objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Capture(context.Background(), c, "prod", []string{"dev"})
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	return s
+}
+
+func TestWriteRead(t *testing.T) {
+	s := captureRoundTrip(t)
+
+	for _, name := range []string{"snapshot", "snapshot.tar.gz", "snapshot.tgz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			err := s.Write(path)
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if !IsSnapshot(path) {
+				t.Errorf("IsSnapshot(%s) = false, want true", name)
+			}
+			if info, err := os.Stat(path); err != nil || info.IsDir() == IsArchive(path) {
+				t.Errorf("%s was not written as a %s", name, map[bool]string{true: "archive", false: "directory"}[IsArchive(path)])
+			}
+
+			md, objs, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if md.FormatVersion != FormatVersion || md.Cluster != "prod" || !slices.Equal(md.Namespaces, []string{"dev"}) ||
+				!md.CapturedAt.Equal(s.Metadata.CapturedAt) {
+				t.Errorf("Read() metadata = %+v, want %+v", md, s.Metadata)
+			}
+			want := []string{"Namespace//dev", "Pod/dev/app", "ResourceQuota/dev/widgets", "Widget/dev/spinner"}
+			if got := objectKeys(t, objs); !slices.Equal(got, want) {
+				t.Errorf("Read() objects = %v, want %v", got, want)
+			}
+
+			// Only the identity of the counted custom resource was captured, which is still enough to count it when replaying
+			rc, err := kubernetes.NewClientForObjects(objs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := rc.CountObjects(context.Background(), "dev", schema.GroupResource{Group: "example.com", Resource: "widgets"})
+			if err != nil || count != 1 {
+				t.Errorf("replayed CountObjects() = %d, %v, want 1", count, err)
+			}
+		})
+	}
+}
+
+func TestReadNewerFormatVersion(t *testing.T) {
+	s := captureRoundTrip(t)
+	s.Metadata.FormatVersion = FormatVersion + 1
+
+	for _, name := range []string{"snapshot", "snapshot.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			err := s.Write(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = Read(path)
+			if err == nil || !strings.Contains(err.Error(), "please upgrade kube-quota") {
+				t.Errorf("Read() error = %v, want the format version to be rejected", err)
+			}
+		})
+	}
+}
+
+func TestReadNotASnapshot(t *testing.T) {
+	dir := t.TempDir()
+	manifests := filepath.Join(dir, "pods.json")
+	data, err := json.Marshal(map[string]string{"apiVersion": "v1", "kind": "PodList"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(manifests, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if IsSnapshot(dir) || IsSnapshot(manifests) {
+		t.Errorf("IsSnapshot() = true for a directory of manifests")
+	}
+	_, _, err = Read(dir)
+	if err == nil || !strings.Contains(err.Error(), "is not a kube-quota snapshot") {
+		t.Errorf("Read() error = %v, want it to not be a snapshot", err)
+	}
+	_, _, err = Read(filepath.Join(dir, "missing.tar.gz"))
+	if err == nil {
+		t.Error("Read() expected an error for a missing archive")
+	}
+}
@@ -0,0 +1,287 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"github.com/aauren/kube-quota/pkg/quota"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// FormatVersion is the version of the snapshot layout that this build writes. It is bumped whenever the layout changes in a way
+	// that older builds could not read.
+	FormatVersion = 1
+
+	MetadataFilename = "metadata.json"
+	// ClusterResourceQuotasFilename holds the ClusterResourceQuotas that apply to any of the captured namespaces. It sits next to the
+	// metadata rather than in a namespace's directory since a single quota can apply to several of them.
+	ClusterResourceQuotasFilename = "clusterresourcequotas.json"
+)
+
+// Metadata describes where and when a snapshot was captured
+type Metadata struct {
+	FormatVersion int       `json:"formatVersion"`
+	Cluster       string    `json:"cluster,omitempty"`
+	ServerVersion string    `json:"serverVersion,omitempty"`
+	CapturedAt    time.Time `json:"capturedAt"`
+	Namespaces    []string  `json:"namespaces"`
+}
+
+// Snapshot holds every object that kube-quota reads for a set of namespaces so that it can be written out and replayed later
+type Snapshot struct {
+	Metadata *Metadata
+	// Files maps the path of a file within the snapshot to the list object that is stored in it
+	Files map[string]runtime.Object
+}
+
+// capturer lists a single kind of object in a namespace and returns it as a list with its TypeMeta filled in
+type capturer struct {
+	filename string
+	capture  func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error)
+}
+
+// capturers holds every kind of object that kube-quota reads, any new lookup that commands perform should be added here as well so
+// that commands keep working against snapshots
+var capturers = []capturer{
+	{
+		filename: "namespace.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			nl := &v1.NamespaceList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "NamespaceList"}}
+			n, err := client.GetNamespace(ctx, ns)
+			if apierrors.IsNotFound(err) {
+				// Like every other kind of object, a namespace that doesn't exist is captured as an empty list
+				return nl, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			nl.Items = append(nl.Items, *n)
+			return nl, nil
+		},
+	},
+	{
+		filename: "pods.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			pl, err := client.GetPodsByNamespace(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			pl.APIVersion, pl.Kind = "v1", "PodList"
+			return pl, nil
+		},
+	},
+	{
+		filename: "resourcequotas.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			rql, err := client.ListQuotasByNS(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			rql.APIVersion, rql.Kind = "v1", "ResourceQuotaList"
+			return rql, nil
+		},
+	},
+	{
+		filename: "persistentvolumeclaims.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			pvcl, err := client.ListClaimsByNS(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			pvcl.APIVersion, pvcl.Kind = "v1", "PersistentVolumeClaimList"
+			return pvcl, nil
+		},
+	},
+	{
+		filename: "services.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			svcl, err := client.ListServicesByNS(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			svcl.APIVersion, svcl.Kind = "v1", "ServiceList"
+			return svcl, nil
+		},
+	},
+	{
+		filename: "limitranges.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			lrl, err := client.ListLimitRangesByNS(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			lrl.APIVersion, lrl.Kind = "v1", "LimitRangeList"
+			return lrl, nil
+		},
+	},
+	{
+		filename: "objects.json",
+		capture:  captureCountedObjects,
+	},
+	{
+		filename: "replicasets.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			rsl, err := client.ListReplicaSetsByNS(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			rsl.APIVersion, rsl.Kind = "apps/v1", "ReplicaSetList"
+			return rsl, nil
+		},
+	},
+	{
+		filename: "jobs.json",
+		capture: func(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+			jl, err := client.ListJobsByNS(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			jl.APIVersion, jl.Kind = "batch/v1", "JobList"
+			return jl, nil
+		},
+	},
+}
+
+// captureCountedObjects captures every object that the namespace's quotas (and the cluster quotas that apply to it) count, other than
+// the pods, claims and services that are captured in full by their own capturers. Only the identity of each object is kept, counting
+// them doesn't need anything else and this keeps the contents of secrets out of the snapshot.
+func captureCountedObjects(ctx context.Context, client *kubernetes.Client, ns string) (runtime.Object, error) {
+	rql, err := client.ListQuotasByNS(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	kqs := make([]*quota.KubeQuota, 0, len(rql.Items))
+	for idx := range rql.Items {
+		kqs = append(kqs, quota.ForKubeQuota(&rql.Items[idx]))
+	}
+	crqs, err := client.ListClusterResourceQuotas(ctx, ns)
+	if err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, crq := range crqs {
+		kqs = append(kqs, quota.ForKubeQuota(crq.ResourceQuota()))
+	}
+
+	list := unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}
+	for _, name := range quota.ObjectCountNames(kqs...) {
+		if name == v1.ResourcePods || quota.IsServiceCount(name) {
+			continue
+		}
+		err = client.ForEachObjectMetadata(ctx, ns, quota.ObjectCountResource(name), func(obj *metav1.PartialObjectMetadata) error {
+			item := unstructured.Unstructured{}
+			item.SetAPIVersion(obj.APIVersion)
+			item.SetKind(obj.Kind)
+			item.SetNamespace(obj.GetNamespace())
+			item.SetName(obj.GetName())
+			list.Items = append(list.Items, item)
+			return nil
+		})
+		if meta.IsNoMatchError(err) {
+			// Quotas can count resources that the API server doesn't serve, there is simply nothing to capture for them
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not capture %s: %w", name, err)
+		}
+	}
+	return &list, nil
+}
+
+// captureClusterResourceQuotas captures the ClusterResourceQuotas that apply to any of the namespaces, each one only once. They are read
+// through the AppliedClusterResourceQuotas of every namespace, so the status of each quota still covers every namespace that it selects.
+// Clusters other than OpenShift don't serve them, in which case nil is returned.
+func captureClusterResourceQuotas(ctx context.Context, client *kubernetes.Client, namespaces []string) (runtime.Object, error) {
+	list := unstructured.UnstructuredList{Object: map[string]interface{}{"apiVersion": "v1", "kind": "List"}}
+	seen := make(map[string]bool)
+	for _, ns := range namespaces {
+		crqs, err := client.ListClusterResourceQuotas(ctx, ns)
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not capture cluster resource quotas of namespace %s: %w", ns, err)
+		}
+		for _, crq := range crqs {
+			if seen[crq.Name] {
+				continue
+			}
+			seen[crq.Name] = true
+			u, err := crq.ToUnstructured()
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, *u)
+		}
+	}
+	return &list, nil
+}
+
+// AccessChecks returns the permissions that Capture needs for the given namespaces. Objects that quotas count are only known once the
+// quotas have been read, and ClusterResourceQuotas are only served by OpenShift, so the permissions to list either of them are not
+// checked up front.
+func AccessChecks(namespaces []string) []kubernetes.AccessCheck {
+	checks := make([]kubernetes.AccessCheck, 0)
+	for _, ns := range namespaces {
+		checks = append(checks, kubernetes.NamespaceAccessChecks(ns)...)
+		checks = append(checks, kubernetes.PodAccessChecks(ns)...)
+		checks = append(checks, kubernetes.QuotaAccessChecks(ns, "")...)
+		checks = append(checks, kubernetes.ClaimAccessChecks(ns)...)
+		checks = append(checks, kubernetes.ServiceAccessChecks(ns)...)
+		checks = append(checks, kubernetes.LimitRangeAccessChecks(ns)...)
+		checks = append(checks, kubernetes.OwnerAccessChecks(ns)...)
+	}
+	return checks
+}
+
+// Capture reads every object that kube-quota uses from the given namespaces
+func Capture(ctx context.Context, client *kubernetes.Client, cluster string, namespaces []string) (*Snapshot, error) {
+	s := Snapshot{
+		Metadata: &Metadata{
+			FormatVersion: FormatVersion,
+			Cluster:       cluster,
+			CapturedAt:    time.Now().UTC(),
+			Namespaces:    namespaces,
+		},
+		Files: make(map[string]runtime.Object),
+	}
+
+	sv, err := client.Interface().Discovery().ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("could not get server version: %w", err)
+	}
+	s.Metadata.ServerVersion = sv.GitVersion
+
+	for _, ns := range namespaces {
+		for _, c := range capturers {
+			obj, err := c.capture(ctx, client, ns)
+			if err != nil {
+				return nil, fmt.Errorf("could not capture %s in namespace %s: %w", c.filename, ns, err)
+			}
+			s.Files[path.Join(ns, c.filename)] = obj
+		}
+	}
+
+	crql, err := captureClusterResourceQuotas(ctx, client, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	if crql != nil {
+		s.Files[ClusterResourceQuotasFilename] = crql
+	}
+
+	return &s, nil
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aauren/kube-quota/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const clusterQuotaYAML = `apiVersion: v1
+kind: Namespace
+metadata: {name: dev, labels: {team: a}}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: dev}
+---
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata: {name: team-a}
+spec:
+  selector:
+    labels: {matchLabels: {team: a}}
+  quota:
+    hard: {count/configmaps: "5"}
+status:
+  namespaces:
+  - namespace: dev
+    status:
+      used: {count/configmaps: "1"}
+  - namespace: prod
+    status:
+      used: {count/configmaps: "0"}
+`
+
+// fileNames returns the name of every object in a captured file
+func fileNames(t *testing.T, s *Snapshot, name string) []string {
+	t.Helper()
+	obj, ok := s.Files[name]
+	if !ok {
+		t.Fatalf("snapshot has no %s", name)
+	}
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		t.Fatalf("%s is not a list: %v", name, err)
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, accessor.GetName())
+	}
+	return names
+}
+
+func TestCaptureClusterQuotas(t *testing.T) {
+	objs, err := kubernetes.ReadObjects(strings.NewReader(clusterQuotaYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := kubernetes.NewClientForObjects(objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Capture(context.Background(), c, "", []string{"dev", "prod", "missing"})
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{file: "dev/namespace.json", want: []string{"dev"}},
+		{file: "missing/namespace.json", want: []string{}},
+		{file: "dev/objects.json", want: []string{"settings"}},
+		// The quota applies to both namespaces but is only stored once
+		{file: ClusterResourceQuotasFilename, want: []string{"team-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := fileNames(t, s, tt.file); !slices.Equal(got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+
+	// Replaying the snapshot serves the quota again, along with its status for each namespace
+	replayed := make([]runtime.Object, 0)
+	for _, obj := range s.Files {
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replayed = append(replayed, items...)
+	}
+	rc, err := kubernetes.NewClientForObjects(replayed...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crqs, err := rc.ListClusterResourceQuotas(context.Background(), "prod")
+	if err != nil || len(crqs) != 1 || len(crqs[0].Status.Namespaces) != 2 {
+		t.Errorf("replayed ListClusterResourceQuotas() = %v, %v, want team-a with the status of both namespaces", crqs, err)
+	}
+}
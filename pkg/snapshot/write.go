@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	dirPerms  = 0o755
+	filePerms = 0o644
+)
+
+// IsArchive returns true if the path names a gzipped tarball rather than a directory
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// Write writes the snapshot to path, as a gzipped tarball when the path ends in .tar.gz or .tgz and as a directory otherwise
+func (s *Snapshot) Write(path string) error {
+	files, err := s.marshalFiles()
+	if err != nil {
+		return err
+	}
+
+	if IsArchive(path) {
+		return writeArchive(path, files)
+	}
+	return writeDir(path, files)
+}
+
+// marshalFiles returns the contents of every file in the snapshot keyed by its path within the snapshot
+func (s *Snapshot) marshalFiles() (map[string][]byte, error) {
+	files := make(map[string][]byte, len(s.Files)+1)
+
+	md, err := marshal(s.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	files[MetadataFilename] = md
+
+	for name, obj := range s.Files {
+		data, err := marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+
+	return files, nil
+}
+
+func writeDir(dir string, files map[string][]byte) error {
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(p), dirPerms)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(p, data, filePerms)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeArchive(path string, files map[string][]byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	// Write the files in a stable order with metadata first, so that it can be found quickly when reading
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if name != MetadataFilename {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{MetadataFilename}, names...)
+
+	for _, name := range names {
+		data := files[name]
+		err = tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: filePerms,
+			Size: int64(len(data)),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	err = gw.Close()
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -3,7 +3,8 @@ package kubequota
 type CPUMilicore int64
 type MemBytes int64
 type StorageBytes int64
-type ClaimsNum int16
+type ClaimsNum int64
+type ResourceNum int64
 type DivideByZeroError struct {
 	message string
 }
@@ -2,6 +2,7 @@ package unit
 
 import (
 	"fmt"
+	"strings"
 
 	kubequota "github.com/aauren/kube-quota/pkg"
 )
@@ -13,10 +14,12 @@ const (
 	Cores
 	PercentBytes
 	PercentCores
+	Count
+	PercentCount
 )
 
 var (
-	AllFormatters = []FormatUnit{Bytes, Cores, PercentBytes, PercentCores}
+	AllFormatters = []FormatUnit{Bytes, Cores, PercentBytes, PercentCores, Count, PercentCount}
 )
 
 type Byter interface {
@@ -27,6 +30,7 @@ type Unit struct {
 	bytes      Byter
 	unit       FormatUnit
 	cores      kubequota.CPUMilicore
+	count      int64
 	percentage kubequota.Percentage
 }
 
@@ -54,6 +58,14 @@ func (u *Unit) String() string {
 			return "NaN"
 		}
 		return fmt.Sprintf("%s (%.2f%%)", formatMilliCores(c), p)
+	case Count:
+		return fmt.Sprintf("%d", u.count)
+	case PercentCount:
+		p, err := u.percentage.Percentage()
+		if err != nil {
+			return "NaN"
+		}
+		return fmt.Sprintf("%d (%.2f%%)", u.percentage.Parts, p)
 	}
 
 	return ""
@@ -77,7 +89,16 @@ func NewUnitWriter(value interface{}, unit FormatUnit) (UnitWriter, error) {
 		default:
 			return nil, fmt.Errorf("unable to cast %v to a valid core type, cannot continue", value)
 		}
-	case PercentBytes, PercentCores:
+	case Count:
+		switch c := value.(type) {
+		case kubequota.ClaimsNum:
+			return &Unit{count: int64(c), unit: unit}, nil
+		case kubequota.ResourceNum:
+			return &Unit{count: int64(c), unit: unit}, nil
+		default:
+			return nil, fmt.Errorf("unable to cast %v to a valid count type, cannot continue", value)
+		}
+	case PercentBytes, PercentCores, PercentCount:
 		switch pb := value.(type) {
 		case *kubequota.Percentage:
 			return &Unit{percentage: *pb, unit: unit}, nil
@@ -98,6 +119,10 @@ func formatBytes(byter Byter) string {
 	)
 
 	bytes := byter.ToBytes()
+	if bytes < 0 {
+		neg := kubequota.StorageBytes(-bytes)
+		return "-" + formatBytes(&neg)
+	}
 
 	switch {
 	case bytes < KB:
@@ -118,9 +143,32 @@ func formatMilliCores(cores kubequota.CPUMilicore) string {
 		Core = 1000
 	)
 
+	if cores < 0 {
+		return "-" + formatMilliCores(-cores)
+	}
+
 	if cores < Core {
 		return fmt.Sprintf("%d Millicores", cores)
 	} else {
 		return fmt.Sprintf("%.1f Cores", float64(cores)/Core)
 	}
 }
+
+type deltaWriter struct {
+	value  UnitWriter
+	change UnitWriter
+}
+
+// NewDeltaWriter returns a UnitWriter that writes a value along with how much it changed, for instance since it was last displayed.
+// Positive changes are prefixed with a +, negative changes already carry their sign.
+func NewDeltaWriter(value, change UnitWriter) UnitWriter {
+	return &deltaWriter{value: value, change: change}
+}
+
+func (d *deltaWriter) String() string {
+	change := d.change.String()
+	if !strings.HasPrefix(change, "-") {
+		change = "+" + change
+	}
+	return fmt.Sprintf("%s [%s]", d.value, change)
+}